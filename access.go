@@ -0,0 +1,71 @@
+package rest
+
+import (
+	"log"
+	"net"
+	"net/http"
+)
+
+// AccessList filters the requests according to the address of the client, as returned by ClientIP.
+// Use its Middleware method on a Group (or a single Controller) to enforce it.
+type AccessList struct {
+	// Allow lists the networks allowed to access the routes. All the addresses are allowed if empty.
+	Allow []*net.IPNet
+	// Deny lists the networks denied, it takes precedence over Allow.
+	Deny []*net.IPNet
+	// Audit logs the rejected addresses.
+	Audit bool
+}
+
+// NewAccessList creates an AccessList from CIDRs or single addresses
+func NewAccessList(allow, deny []string) (*AccessList, error) {
+	var err error
+
+	a := new(AccessList)
+	a.Allow, err = parseCIDRs(allow)
+	if err != nil {
+		return nil, err
+	}
+	a.Deny, err = parseCIDRs(deny)
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		ipnet, err := parseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, ipnet)
+	}
+	return nets, nil
+}
+
+// Allowed tells whether ip may access the routes. An unknown (nil) address is only allowed if there is no allowlist.
+func (a *AccessList) Allowed(ip net.IP) bool {
+	if ip == nil {
+		return len(a.Allow) == 0
+	}
+	if containsIP(a.Deny, ip) == true {
+		return false
+	}
+	return len(a.Allow) == 0 || containsIP(a.Allow, ip) == true
+}
+
+// Middleware rejects the requests from the addresses not allowed with a 403
+func (a *AccessList) Middleware(next Controller) Controller {
+	return func(r *http.Request, p Params) (interface{}, error) {
+		ip := ClientIP(r)
+		if a.Allowed(ip) == false {
+			if a.Audit == true {
+				log.Printf("access denied: %s %s from %s\n", r.Method, r.URL.Path, ip)
+			}
+			return nil, NewAPIError(403, "access denied")
+		}
+		return next(r, p)
+	}
+}
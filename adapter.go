@@ -0,0 +1,80 @@
+package rest
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// bufferedResponseWriter keeps the response in memory
+type bufferedResponseWriter struct {
+	h    http.Header
+	code int
+	body bytes.Buffer
+}
+
+func (w *bufferedResponseWriter) Header() http.Header {
+	return w.h
+}
+
+func (w *bufferedResponseWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+}
+
+func (w *bufferedResponseWriter) Write(data []byte) (int, error) {
+	w.WriteHeader(200)
+	return w.body.Write(data)
+}
+
+// rawResp is a response already encoded
+type rawResp struct {
+	code  int
+	ctype string
+	data  []byte
+}
+
+func (r rawResp) StatusCode() int {
+	return r.code
+}
+
+func (r rawResp) Location() string {
+	return ""
+}
+
+func (r rawResp) ContentType() string {
+	return r.ctype
+}
+
+func (r rawResp) Data() []byte {
+	return r.data
+}
+
+// resp converts the buffered response to a controller response
+func (w *bufferedResponseWriter) resp() interface{} {
+	ctype := w.h.Get("Content-Type")
+	w.h.Del("Content-Type")
+	w.h.Del("Content-Length")
+	return headerResp{
+		value: rawResp{w.code, ctype, w.body.Bytes()},
+		h:     w.h,
+	}
+}
+
+// serveBuffered serves the request with h, keeping the response in memory
+func serveBuffered(h http.Handler, r *http.Request) *bufferedResponseWriter {
+	w := &bufferedResponseWriter{h: make(http.Header)}
+	h.ServeHTTP(w, r)
+	if w.code == 0 {
+		w.code = 200
+	}
+	return w
+}
+
+// HandlerController adapts an http.Handler to a Controller, so that it runs behind the middlewares of the router.
+// The responses are buffered, so it is not suited to streaming handlers.
+func HandlerController(h http.Handler) Controller {
+	return func(r *http.Request, p Params) (interface{}, error) {
+		return serveBuffered(h, r).resp(), nil
+	}
+}
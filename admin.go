@@ -0,0 +1,186 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LevelSetter is implemented by the loggers whose level can be changed at runtime
+type LevelSetter interface {
+	SetLevel(level Level)
+}
+
+// SetLogLevel changes the minimum level of the logs of the router. It replaces the default logger and the
+// StdLogger, and calls SetLevel on the other loggers implementing LevelSetter.
+func (r *Router) SetLogLevel(level Level) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch logger := r.logger.(type) {
+	case nil, StdLogger:
+		r.logger = StdLogger{level}
+	case LevelSetter:
+		logger.SetLevel(level)
+	}
+}
+
+// logLevel returns the level of the logger of the router, if known
+func (r *Router) logLevel() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	switch logger := r.logger.(type) {
+	case nil:
+		return LevelInfo.String()
+	case StdLogger:
+		return logger.Level.String()
+	}
+	return ""
+}
+
+// Admin exposes the runtime configuration of a router, see Router.Admin
+type Admin struct {
+	// RateLimiters are the rate limiters whose limits can be changed, by name
+	RateLimiters map[string]*RateLimiter
+	// Auditor records the changes, they are logged if nil
+	Auditor *Auditor
+}
+
+// AdminConfig is the runtime configuration returned by the admin API
+type AdminConfig struct {
+	LogLevel    string `json:",omitempty"`
+	Maintenance bool
+	RateLimits  map[string]AdminRateLimit `json:",omitempty"`
+	Flags       map[string]bool           `json:",omitempty"`
+}
+
+// AdminFlag enables or disables a feature flag in the admin API
+type AdminFlag struct {
+	Enabled bool
+}
+
+// AdminRateLimit is the limit of a rate limiter in the admin API
+type AdminRateLimit struct {
+	Limit  int
+	Window Duration
+}
+
+// AdminMaintenance starts or stops the maintenance mode in the admin API
+type AdminMaintenance struct {
+	Enabled    bool
+	RetryAfter Duration
+}
+
+// AdminLogLevel changes the log level in the admin API
+type AdminLogLevel struct {
+	Level string
+}
+
+// Admin registers the admin API under prefix, behind auth which must authenticate the administrators. The changes
+// are immediate, and recorded by the auditor of admin or logged:
+//
+//	GET  /config              the current configuration (AdminConfig)
+//	PUT  /log-level           {"Level": "debug"}
+//	PUT  /maintenance         {"Enabled": true, "RetryAfter": "5m"}, the admin API stays available
+//	PUT  /rate-limits/:name   {"Limit": 100, "Window": "1m"}
+//	PUT  /flags/:name         {"Enabled": true}, if the flag provider of the router implements FlagSetter
+func (r *Router) Admin(prefix string, auth Middleware, admin Admin) *Group {
+	if auth == nil {
+		panic("the admin API must be authenticated")
+	}
+	g := r.Group(prefix, auth)
+	audit := func(action string, ctrl Controller) Controller {
+		if admin.Auditor != nil {
+			return admin.Auditor.Audit(action)(ctrl)
+		}
+		return func(req *http.Request, p Params) (interface{}, error) {
+			resp, err := ctrl(req, p)
+			if err == nil {
+				Log(req).Logf(LevelWarn, "admin: %s by %s\n", action, ClientIP(req))
+			}
+			return resp, err
+		}
+	}
+
+	g.GET("/config", func(req *http.Request, p Params) (interface{}, error) {
+		config := AdminConfig{
+			LogLevel:    r.logLevel(),
+			Maintenance: r.InMaintenance(),
+			RateLimits:  make(map[string]AdminRateLimit),
+		}
+		for name, limiter := range admin.RateLimiters {
+			limit, window := limiter.Limit()
+			config.RateLimits[name] = AdminRateLimit{limit, Duration(window)}
+		}
+		if setter, ok := r.flagProvider().(FlagSetter); ok == true {
+			config.Flags = setter.Flags()
+		}
+		return config, nil
+	})
+	g.PUT("/log-level", audit("admin.log-level", func(req *http.Request, p Params) (interface{}, error) {
+		var body AdminLogLevel
+		if err := Parse(req, &body); err != nil {
+			return nil, err
+		}
+		level, err := ParseLevel(body.Level)
+		if err != nil {
+			return nil, NewAPIError(400, err.Error())
+		}
+		r.SetLogLevel(level)
+		SetAuditDetail(req, "level="+level.String())
+		return body, nil
+	}))
+	g.PUT("/maintenance", audit("admin.maintenance", func(req *http.Request, p Params) (interface{}, error) {
+		var body AdminMaintenance
+		if err := Parse(req, &body); err != nil {
+			return nil, err
+		}
+		if body.Enabled == true {
+			r.StartMaintenance(Maintenance{
+				RetryAfter: time.Duration(body.RetryAfter),
+				Allow:      []string{g.prefix + "/*"},
+			})
+			SetAuditDetail(req, "started")
+		} else {
+			r.StopMaintenance()
+			SetAuditDetail(req, "stopped")
+		}
+		return body, nil
+	}))
+	g.PUT("/rate-limits/:name", audit("admin.rate-limit", func(req *http.Request, p Params) (interface{}, error) {
+		limiter, ok := admin.RateLimiters[p.ByName("name")]
+		if ok == false {
+			names := make([]string, 0, len(admin.RateLimiters))
+			for name := range admin.RateLimiters {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return nil, NewAPIError(404, "unknown rate limiter, the rate limiters are: "+strings.Join(names, ", "))
+		}
+		var body AdminRateLimit
+		if err := Parse(req, &body); err != nil {
+			return nil, err
+		}
+		if body.Limit <= 0 || body.Window <= 0 {
+			return nil, NewAPIError(400, "the limit and the window must be positive")
+		}
+		limiter.SetLimit(body.Limit, time.Duration(body.Window))
+		SetAuditDetail(req, fmt.Sprintf("%s: %d per %s", p.ByName("name"), body.Limit, time.Duration(body.Window)))
+		return body, nil
+	}))
+	g.PUT("/flags/:name", audit("admin.flag", func(req *http.Request, p Params) (interface{}, error) {
+		setter, ok := r.flagProvider().(FlagSetter)
+		if ok == false {
+			return nil, NewAPIError(501, "the flags of the flag provider can't be changed")
+		}
+		var body AdminFlag
+		if err := Parse(req, &body); err != nil {
+			return nil, err
+		}
+		setter.SetFlag(p.ByName("name"), body.Enabled)
+		SetAuditDetail(req, fmt.Sprintf("%s: %t", p.ByName("name"), body.Enabled))
+		return body, nil
+	}))
+	return g
+}
@@ -0,0 +1,152 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// AuditEntry is a record of the audit trail
+type AuditEntry struct {
+	Time     time.Time
+	Actor    string
+	Action   string
+	Method   string
+	Resource string
+	ClientIP string
+	Payload  string `json:",omitempty"`
+	Detail   string `json:",omitempty"`
+	Status   int
+	Error    string `json:",omitempty"`
+	Duration time.Duration
+}
+
+// AuditSink stores the audit trail (file, database, message queue...)
+type AuditSink interface {
+	Record(entry AuditEntry) error
+}
+
+// AuditSinkFunc adapts a function to an AuditSink
+type AuditSinkFunc func(entry AuditEntry) error
+
+// Record calls f
+func (f AuditSinkFunc) Record(entry AuditEntry) error {
+	return f(entry)
+}
+
+// writerSink writes the entries as JSON lines
+type writerSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterSink creates an AuditSink writing the entries to w (a file for instance) as JSON lines
+func NewWriterSink(w io.Writer) AuditSink {
+	return &writerSink{w: w}
+}
+
+func (s *writerSink) Record(entry AuditEntry) error {
+	chunk, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(chunk, '\n'))
+	return err
+}
+
+// Auditor records the calls of the auditable routes to its sink
+type Auditor struct {
+	Sink AuditSink
+	// Actor returns who is doing the request, from the authentication set by the middlewares. May be nil.
+	Actor func(r *http.Request) string
+	// MaxPayload is the number of bytes of the request body kept in the entries, 0 to not keep the payload.
+	MaxPayload int
+}
+
+// NewAuditor creates an Auditor keeping the first KiB of the payloads
+func NewAuditor(sink AuditSink, actor func(r *http.Request) string) *Auditor {
+	return &Auditor{
+		Sink:       sink,
+		Actor:      actor,
+		MaxPayload: 1024,
+	}
+}
+
+// Audit returns a Middleware marking the routes as auditable: every call is recorded under action, with its outcome
+//
+//	group.DELETE("/users/:id", auditor.Audit("user.delete")(deleteUser))
+func (a *Auditor) Audit(action string) Middleware {
+	return func(next Controller) Controller {
+		return func(r *http.Request, p Params) (interface{}, error) {
+			start := time.Now()
+			entry := &AuditEntry{
+				Time:     start,
+				Action:   action,
+				Method:   r.Method,
+				Resource: r.URL.Path,
+			}
+			if ip := ClientIP(r); ip != nil {
+				entry.ClientIP = ip.String()
+			}
+			if a.Actor != nil {
+				entry.Actor = a.Actor(r)
+			}
+			if a.MaxPayload > 0 && r.Body != nil {
+				payload, _ := ioutil.ReadAll(io.LimitReader(r.Body, int64(a.MaxPayload)))
+				entry.Payload = routerFromRequest(r).getRedactor().Body(string(payload))
+				r.Body = readCloser{io.MultiReader(bytes.NewReader(payload), r.Body), r.Body}
+			}
+
+			resp, err := next(r.WithContext(context.WithValue(r.Context(), auditKey, entry)), p)
+
+			entry.Duration = time.Since(start)
+			entry.Status = responseStatus(routerFromRequest(r), resp, err)
+			if err != nil {
+				entry.Error = err.Error()
+			}
+			err2 := a.Sink.Record(*entry)
+			if err2 != nil {
+				log.Println("error while recording audit entry:", err2)
+			}
+			return resp, err
+		}
+	}
+}
+
+// SetAuditDetail attaches a detail (diff of the resource, summary of the change...) to the audit entry of the request
+func SetAuditDetail(r *http.Request, detail string) {
+	if entry, ok := r.Context().Value(auditKey).(*AuditEntry); ok == true {
+		entry.Detail = detail
+	}
+}
+
+// responseStatus returns the status code the handler of router will send for the response of a controller
+func responseStatus(router *Router, resp interface{}, err error) int {
+	if err != nil {
+		return router.mapError(err).StatusCode()
+	}
+	for {
+		wrapper, ok := resp.(respWrapper)
+		if ok == false {
+			break
+		}
+		resp = wrapper.unwrap()
+	}
+	if resp2, ok := resp.(Resp); ok == true && resp2.StatusCode() != 0 {
+		return resp2.StatusCode()
+	}
+	return 200
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
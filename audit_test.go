@@ -0,0 +1,39 @@
+package rest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestResponseStatus(t *testing.T) {
+	router := New()
+	router.MapError(sql.ErrNoRows, 404, "not found")
+	tests := []struct {
+		name    string
+		err     error
+		status  int
+		message string
+	}{
+		{"none", nil, 200, ""},
+		{"api error", NewAPIError(409, "conflict"), 409, "conflict"},
+		{"wrapped", fmt.Errorf("loading: %w", NewAPIError(404, "no such user")), 404, "no such user"},
+		{"mapped", fmt.Errorf("query: %w", sql.ErrNoRows), 404, "not found"},
+		{"other", errors.New("connection refused"), 500, NewError500().Message},
+	}
+	for _, test := range tests {
+		if status := responseStatus(router, nil, test.err); status != test.status {
+			t.Errorf("%s: got %d, expected %d", test.name, status, test.status)
+		}
+		if test.err == nil {
+			continue
+		}
+		ctx := context.WithValue(context.Background(), routerKey, router)
+		gqlErr := NewGraphQLError(ctx, test.err, "user")
+		if gqlErr.Extensions["status"] != test.status || gqlErr.Message != test.message {
+			t.Errorf("%s: got %+v", test.name, gqlErr)
+		}
+	}
+}
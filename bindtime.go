@@ -0,0 +1,142 @@
+package rest
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	// timeFields caches whether the types have time.Time or time.Duration fields
+	timeFields sync.Map
+)
+
+// SetTimeLayout sets the layout (see time.Parse) of the time.Time fields bound by Parse and ParseQuery, tried before
+// RFC 3339 and the Unix timestamps
+func (r *Router) SetTimeLayout(layout string) {
+	r.mu.Lock()
+	r.timeLayout = layout
+	r.mu.Unlock()
+}
+
+// timeLayout returns the time layout of the router serving the request, empty if it has none
+func timeLayout(r *http.Request) string {
+	router := routerFromRequest(r)
+	if router == nil {
+		return ""
+	}
+	router.mu.RLock()
+	defer router.mu.RUnlock()
+	return router.timeLayout
+}
+
+// parseTime parses a time with the layout of the router, as RFC 3339 or as a Unix timestamp in seconds or in
+// milliseconds (the timestamps above 10^11 are in milliseconds)
+func parseTime(s string, layout string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if layout != "" {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if n >= 1e11 || n <= -1e11 {
+			return time.Unix(n/1000, n%1000*int64(time.Millisecond)).UTC(), nil
+		}
+		return time.Unix(n, 0).UTC(), nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil && math.IsInf(f, 0) == false && math.IsNaN(f) == false {
+		return epoch(f), nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not a time (RFC 3339 or Unix timestamp)", s)
+}
+
+// epoch converts a Unix timestamp in seconds or in milliseconds
+func epoch(f float64) time.Time {
+	if math.Abs(f) >= 1e11 {
+		return time.Unix(0, int64(f*float64(time.Millisecond))).UTC()
+	}
+	return time.Unix(0, int64(f*float64(time.Second))).UTC()
+}
+
+// parseDuration parses a Go duration ("1h30m") or a number of seconds
+func parseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if d, err := time.ParseDuration(s); err == nil {
+		return d, nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Duration(f * float64(time.Second)), nil
+	}
+	return 0, fmt.Errorf("%q is not a duration", s)
+}
+
+// coerceTime converts a JSON value for a time.Time or time.Duration field, encoding/json only supports RFC 3339 and
+// nanoseconds. The durations are Go durations or numbers of seconds, quoted or not, as in the forms and the query.
+func coerceTime(value interface{}, t reflect.Type, layout string) (interface{}, error) {
+	var s string
+
+	switch v := value.(type) {
+	case string:
+		s = v
+	case fmt.Stringer: // json.Number
+		s = v.String()
+	default:
+		return value, nil
+	}
+	if t == timeType {
+		parsed, err := parseTime(s, layout)
+		if err != nil {
+			return nil, err
+		}
+		return parsed.Format(time.RFC3339Nano), nil
+	}
+	d, err := parseDuration(s)
+	if err != nil {
+		return nil, err
+	}
+	return int64(d), nil
+}
+
+// hasTimeFields returns whether the values of t contain time.Time or time.Duration fields
+func hasTimeFields(t reflect.Type) bool {
+	if found, ok := timeFields.Load(t); ok == true {
+		return found.(bool)
+	}
+	found := searchTimeFields(t, make(map[reflect.Type]bool))
+	timeFields.Store(t, found)
+	return found
+}
+
+func searchTimeFields(t reflect.Type, seen map[reflect.Type]bool) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType || t == durationType {
+		return true
+	}
+	if seen[t] == true {
+		return false
+	}
+	seen[t] = true
+	switch t.Kind() {
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if searchTimeFields(t.Field(i).Type, seen) == true {
+				return true
+			}
+		}
+	case reflect.Map, reflect.Slice, reflect.Array:
+		return searchTimeFields(t.Elem(), seen)
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package rest
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDurationUnit(t *testing.T) {
+	type body struct {
+		Timeout time.Duration `json:"timeout"`
+	}
+	tests := []struct {
+		ctype    string
+		body     string
+		expected time.Duration
+	}{
+		{"application/json", `{"timeout": 90}`, 90 * time.Second},
+		{"application/json", `{"timeout": "90"}`, 90 * time.Second},
+		{"application/json", `{"timeout": 1.5}`, 1500 * time.Millisecond},
+		{"application/json", `{"timeout": "1m30s"}`, 90 * time.Second},
+		{"application/x-www-form-urlencoded", "timeout=90", 90 * time.Second},
+		{"application/x-www-form-urlencoded", "timeout=1m30s", 90 * time.Second},
+	}
+	for _, test := range tests {
+		var v body
+		req := httptest.NewRequest("POST", "/", strings.NewReader(test.body))
+		req.Header.Set("Content-Type", test.ctype)
+		if err := Parse(req, &v); err != nil {
+			t.Errorf("%s: %s", test.body, err)
+		} else if v.Timeout != test.expected {
+			t.Errorf("%s: got %s, expected %s", test.body, v.Timeout, test.expected)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CacheOptions are the optional parameters of Cacheable
+type CacheOptions struct {
+	// Private forbids shared caches (proxies, CDN) to store the response, only the client may cache it.
+	Private bool
+	// Immutable tells the client the response will never change during its lifetime.
+	Immutable bool
+	// StaleWhileRevalidate allows caches to serve a stale response while revalidating it in the background.
+	StaleWhileRevalidate time.Duration
+	// StaleIfError allows caches to serve a stale response when the server fails.
+	StaleIfError time.Duration
+	// Vary lists the request headers the response depends on.
+	Vary []string
+}
+
+// respWrapper is implemented by the responses wrapping another response to add headers to it
+type respWrapper interface {
+	header() http.Header
+	unwrap() interface{}
+}
+
+// headerResp adds headers to the response it wraps
+type headerResp struct {
+	value interface{}
+	h     http.Header
+}
+
+func (c headerResp) header() http.Header {
+	return c.h
+}
+
+func (c headerResp) unwrap() interface{} {
+	return c.value
+}
+
+// Cacheable wraps the response v and sets the Cache-Control, Expires and Vary headers according to maxAge and opts.
+// A maxAge lower or equal to 0 makes the response uncacheable without revalidation.
+func Cacheable(v interface{}, maxAge time.Duration, opts CacheOptions) interface{} {
+	var directives []string
+
+	h := make(http.Header)
+	if maxAge <= 0 {
+		directives = append(directives, "no-cache")
+		h.Set("Expires", "0")
+	} else {
+		h.Set("Expires", time.Now().Add(maxAge).UTC().Format(http.TimeFormat))
+	}
+	if opts.Private == true {
+		directives = append(directives, "private")
+	} else {
+		directives = append(directives, "public")
+	}
+	if maxAge > 0 {
+		directives = append(directives, "max-age="+seconds(maxAge))
+	}
+	if opts.Immutable == true {
+		directives = append(directives, "immutable")
+	}
+	if opts.StaleWhileRevalidate > 0 {
+		directives = append(directives, "stale-while-revalidate="+seconds(opts.StaleWhileRevalidate))
+	}
+	if opts.StaleIfError > 0 {
+		directives = append(directives, "stale-if-error="+seconds(opts.StaleIfError))
+	}
+	h.Set("Cache-Control", strings.Join(directives, ", "))
+	if len(opts.Vary) != 0 {
+		h.Set("Vary", strings.Join(opts.Vary, ", "))
+	}
+	return headerResp{
+		value: v,
+		h:     h,
+	}
+}
+
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(d/time.Second), 10)
+}
+
+// applyHeader copies the headers of src into dst, Vary values are merged instead of replaced.
+func applyHeader(dst, src http.Header) {
+	for name, values := range src {
+		if name == "Vary" {
+			for _, value := range values {
+				addVary(dst, strings.Split(value, ", ")...)
+			}
+		} else {
+			dst[name] = values
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// RespCacheTags is implemented by the responses tagged for the CDN: the tags are sent in the Surrogate-Key (Fastly)
+// and Cache-Tag (Cloudflare, Akamai) headers, so that the cached responses can be purged by tag with a Purger.
+type RespCacheTags interface {
+	CacheTags() []string
+}
+
+// CacheTags wraps the response v and tags it for the CDN, see RespCacheTags
+func CacheTags(v interface{}, tags ...string) interface{} {
+	h := make(http.Header)
+	setCacheTags(h, tags)
+	return headerResp{
+		value: v,
+		h:     h,
+	}
+}
+
+func setCacheTags(h http.Header, tags []string) {
+	if len(tags) == 0 {
+		return
+	}
+	h.Set("Surrogate-Key", strings.Join(tags, " "))
+	h.Set("Cache-Tag", strings.Join(tags, ","))
+}
+
+// Purger invalidates the responses cached by the CDN with the given tags, adapt the API of your CDN to it
+type Purger interface {
+	Purge(ctx context.Context, tags ...string) error
+}
+
+// PurgerFunc adapts a function to a Purger
+type PurgerFunc func(ctx context.Context, tags ...string) error
+
+// Purge calls f
+func (f PurgerFunc) Purge(ctx context.Context, tags ...string) error {
+	return f(ctx, tags...)
+}
+
+// SetPurger sets the purger used by Purge
+func (r *Router) SetPurger(p Purger) {
+	r.mu.Lock()
+	r.purger = p
+	r.mu.Unlock()
+}
+
+// Purge invalidates the responses cached by the CDN with the given tags, with the purger of the router. It is meant
+// to be called by the controllers modifying the resources:
+//
+//	err = rest.Purge(r, "user-"+id)
+func Purge(r *http.Request, tags ...string) error {
+	var p Purger
+
+	if router := routerFromRequest(r); router != nil {
+		router.mu.RLock()
+		p = router.purger
+		router.mu.RUnlock()
+	}
+	if p == nil {
+		return errors.New("no purger set")
+	}
+	return p.Purge(r.Context(), tags...)
+}
@@ -0,0 +1,109 @@
+package rest
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Canary splits the traffic of a route between a stable and a canary controller, for a gradual rollout of a
+// rewrite. Upstreams are served with HandlerController. Its Controller is registered like any other:
+//
+//	canary := rest.NewCanary("search", searchV1, searchV2, 0.05)
+//	router.GET("/search", canary.Controller)
+type Canary struct {
+	// Name identifies the canary in the metrics
+	Name   string
+	Stable Controller
+	Canary Controller
+	// Header forces the variant of the requests with a boolean value ("true" for the canary, "false" for the
+	// stable version), if not empty
+	Header string
+	// Cookie keeps the clients on the same variant, if not empty
+	Cookie string
+	// CookieMaxAge is the lifetime of the cookie, 24 hours if 0
+	CookieMaxAge time.Duration
+
+	mu     sync.Mutex
+	weight float64
+	rand   *rand.Rand
+}
+
+// NewCanary creates a Canary sending the given fraction of the traffic (between 0 and 1) to the canary controller
+func NewCanary(name string, stable, canary Controller, weight float64) *Canary {
+	return &Canary{
+		Name:   name,
+		Stable: stable,
+		Canary: canary,
+		weight: weight,
+		rand:   rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// SetWeight changes the fraction of the traffic sent to the canary, it can be called while serving. The clients
+// already assigned to a variant by the cookie keep it.
+func (c *Canary) SetWeight(weight float64) {
+	c.mu.Lock()
+	c.weight = weight
+	c.mu.Unlock()
+}
+
+// variant returns whether the request is served by the canary, and whether the variant was drawn
+func (c *Canary) variant(r *http.Request) (bool, bool) {
+	if c.Header != "" {
+		if forced, err := strconv.ParseBool(r.Header.Get(c.Header)); err == nil {
+			return forced, false
+		}
+	}
+	if c.Cookie != "" {
+		if cookie, err := r.Cookie(c.Cookie); err == nil {
+			if cookie.Value == "canary" {
+				return true, false
+			} else if cookie.Value == "stable" {
+				return false, false
+			}
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rand.Float64() < c.weight, true
+}
+
+// Controller serves the request with the variant it is assigned to
+func (c *Canary) Controller(r *http.Request, p Params) (interface{}, error) {
+	canary, drawn := c.variant(r)
+	name, ctrl := "stable", c.Stable
+	if canary == true {
+		name, ctrl = "canary", c.Canary
+	}
+	if drawn == true && c.Cookie != "" {
+		if w := stateFromRequest(r).w; w != nil {
+			maxAge := c.CookieMaxAge
+			if maxAge == 0 {
+				maxAge = 24 * time.Hour
+			}
+			http.SetCookie(w, &http.Cookie{
+				Name:     c.Cookie,
+				Value:    name,
+				Path:     "/",
+				MaxAge:   int(maxAge / time.Second),
+				HttpOnly: true,
+			})
+		}
+	}
+
+	resp, err := ctrl(r, p)
+
+	if router := routerFromRequest(r); router != nil {
+		tags := stateFromRequest(r).tags()
+		tags["canary"] = c.Name
+		tags["variant"] = name
+		router.incr("rest.canary.requests", tags)
+		if responseStatus(router, resp, err) >= 500 {
+			router.incr("rest.canary.errors", tags)
+		}
+	}
+	return resp, err
+}
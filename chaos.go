@@ -0,0 +1,95 @@
+package rest
+
+import (
+	"math/rand"
+	"net/http"
+	"path"
+	"sync"
+	"time"
+)
+
+// Fault is a fault injected by Chaos
+type Fault struct {
+	// Method restricts the fault to a method, all the methods if empty
+	Method string
+	// Path restricts the fault to the paths matching the pattern (see path.Match), all the paths if empty
+	Path string
+	// Probability is the probability of the fault to be injected, between 0 and 1
+	Probability float64
+	// Latency delays the request
+	Latency time.Duration
+	// Status replies an error with this status, instead of calling the controller, if not 0
+	Status int
+	// Drop closes the connection without replying
+	Drop bool
+}
+
+func (f Fault) matches(r *http.Request) bool {
+	if f.Method != "" && f.Method != r.Method {
+		return false
+	}
+	if f.Path != "" {
+		matched, err := path.Match(f.Path, r.URL.Path)
+		return err == nil && matched == true
+	}
+	return true
+}
+
+// Chaos injects faults in the requests, in order to test the resilience of the clients. It is meant for development
+// and test environments only.
+type Chaos struct {
+	mu     sync.Mutex
+	faults []Fault
+	rand   *rand.Rand
+}
+
+// NewChaos creates a Chaos injecting faults. Every matching fault is drawn independently.
+func NewChaos(faults ...Fault) *Chaos {
+	return &Chaos{
+		faults: faults,
+		rand:   rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// SetFaults replaces the faults injected, it can be called while serving
+func (c *Chaos) SetFaults(faults ...Fault) {
+	c.mu.Lock()
+	c.faults = faults
+	c.mu.Unlock()
+}
+
+// draw returns the faults to inject in the request
+func (c *Chaos) draw(r *http.Request) []Fault {
+	var drawn []Fault
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, fault := range c.faults {
+		if fault.matches(r) == true && c.rand.Float64() < fault.Probability {
+			drawn = append(drawn, fault)
+		}
+	}
+	return drawn
+}
+
+// Middleware injects the faults in the requests
+func (c *Chaos) Middleware(next Controller) Controller {
+	return func(r *http.Request, p Params) (interface{}, error) {
+		for _, fault := range c.draw(r) {
+			if fault.Latency > 0 {
+				select {
+				case <-time.After(fault.Latency):
+				case <-r.Context().Done():
+				}
+			}
+			if fault.Drop == true {
+				// makes net/http close the connection without replying nor logging
+				panic(http.ErrAbortHandler)
+			}
+			if fault.Status != 0 {
+				return nil, NewAPIError(fault.Status, "fault injected: "+http.StatusText(fault.Status))
+			}
+		}
+		return next(r, p)
+	}
+}
@@ -0,0 +1,115 @@
+package rest
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// SetTrustedProxies sets the CIDRs of the reverse proxies allowed to set the client address through the
+// Forwarded, X-Forwarded-For and X-Real-IP headers. A single address is accepted as well.
+func (r *Router) SetTrustedProxies(cidrs ...string) error {
+	nets, err := parseCIDRs(cidrs)
+	if err != nil {
+		return err
+	}
+	r.trustedProxies = nets
+	return nil
+}
+
+// parseCIDR parses a CIDR, a single IP being parsed as a /32 (or /128)
+func parseCIDR(cidr string) (*net.IPNet, error) {
+	if strings.Contains(cidr, "/") == false {
+		ip := net.ParseIP(cidr)
+		if ip == nil {
+			return nil, &net.ParseError{Type: "IP address", Text: cidr}
+		}
+		bits := 8 * net.IPv6len
+		if ip4 := ip.To4(); ip4 != nil {
+			ip = ip4
+			bits = 8 * net.IPv4len
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, ipnet, err := net.ParseCIDR(cidr)
+	return ipnet, err
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, ipnet := range nets {
+		if ipnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the address of the client. The forwarding headers are only used when the request comes from a
+// trusted proxy (see Router.SetTrustedProxies), in which case the first untrusted address of the chain is returned.
+// nil is returned if the address cannot be determined.
+func ClientIP(r *http.Request) net.IP {
+	var trusted []*net.IPNet
+
+	if router := routerFromRequest(r); router != nil {
+		trusted = router.trustedProxies
+	}
+	ip := parseHost(r.RemoteAddr)
+	if ip == nil || containsIP(trusted, ip) == false {
+		return ip
+	}
+
+	chain := forwardedFor(r)
+	if chain == nil {
+		if realIP := parseHost(r.Header.Get("X-Real-IP")); realIP != nil {
+			return realIP
+		}
+		return ip
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		hop := parseHost(chain[i])
+		if hop == nil {
+			// obfuscated or unknown address, the chain cannot be trusted any further
+			return ip
+		}
+		ip = hop
+		if containsIP(trusted, ip) == false {
+			return ip
+		}
+	}
+	return ip
+}
+
+// forwardedFor returns the chain of addresses from the Forwarded header, or from X-Forwarded-For if the former is
+// absent
+func forwardedFor(r *http.Request) []string {
+	var chain []string
+
+	if headers, ok := r.Header["Forwarded"]; ok == true {
+		for _, header := range headers {
+			for _, element := range strings.Split(header, ",") {
+				for _, pair := range strings.Split(element, ";") {
+					kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+					if len(kv) == 2 && strings.EqualFold(kv[0], "for") {
+						chain = append(chain, strings.Trim(kv[1], `"`))
+					}
+				}
+			}
+		}
+		return chain
+	}
+	for _, header := range r.Header["X-Forwarded-For"] {
+		for _, hop := range strings.Split(header, ",") {
+			chain = append(chain, strings.TrimSpace(hop))
+		}
+	}
+	return chain
+}
+
+// parseHost parses an address with an optional port, IPv6 addresses may be enclosed in brackets
+func parseHost(addr string) net.IP {
+	addr = strings.TrimSpace(addr)
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	return net.ParseIP(strings.Trim(addr, "[]"))
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strings"
+
+	"github.com/konek/rest"
+)
+
+// goGenerator generates a Go client, the inline object schemas become named types
+type goGenerator struct {
+	types   bytes.Buffer
+	methods bytes.Buffer
+	schemas map[string]*rest.Schema
+}
+
+func generateGo(doc *rest.OpenAPI, pkg string) ([]byte, error) {
+	g := new(goGenerator)
+	if doc.Components != nil {
+		g.schemas = doc.Components.Schemas
+		for _, name := range sortedKeys(doc.Components.Schemas) {
+			g.namedType(exported(name), doc.Components.Schemas[name])
+		}
+	}
+	for _, op := range operations(doc) {
+		g.method(op)
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by restgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "// Package %s is a client of %s %s\n", pkg, doc.Info.Title, doc.Info.Version)
+	fmt.Fprintf(&b, "package %s\n\n", pkg)
+	b.WriteString(`import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var _ = time.Time{}
+
+// Client calls the API
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	// Header is added to all the requests (authentication...)
+	Header http.Header
+}
+
+// New creates a Client for the API served at baseURL
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		HTTPClient: http.DefaultClient,
+		Header:     make(http.Header),
+	}
+}
+
+// Error is returned when the API replies an error
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d: %s", e.StatusCode, e.Message)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, result interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		chunk, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(chunk)
+	}
+	u := c.BaseURL + path
+	if len(query) != 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(method, u, reader)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	for name, values := range c.Header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		apiErr := &Error{StatusCode: resp.StatusCode}
+		var envelope struct{ Message string }
+		if json.NewDecoder(resp.Body).Decode(&envelope) == nil {
+			apiErr.Message = envelope.Message
+		}
+		return apiErr
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+`)
+	b.Write(g.types.Bytes())
+	b.Write(g.methods.Bytes())
+	return format.Source(b.Bytes())
+}
+
+// goType returns the Go type of a schema, name is used for the inline objects
+func (g *goGenerator) goType(s *rest.Schema, name string) string {
+	if s == nil {
+		return "json.RawMessage"
+	}
+	t := g.baseType(s, name)
+	if s.Nullable == true && strings.HasPrefix(t, "[]") == false && strings.HasPrefix(t, "map[") == false {
+		t = "*" + t
+	}
+	return t
+}
+
+func (g *goGenerator) baseType(s *rest.Schema, name string) string {
+	if s.Ref != "" {
+		return exported(s.Ref[strings.LastIndex(s.Ref, "/")+1:])
+	}
+	switch s.Type {
+	case "string":
+		if s.Format == "date-time" {
+			return "time.Time"
+		} else if s.Format == "byte" {
+			return "[]byte"
+		}
+		return "string"
+	case "integer":
+		return "int64"
+	case "number":
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "array":
+		return "[]" + g.goType(s.Items, name+"Item")
+	case "object":
+		if len(s.Properties) == 0 && s.AdditionalProperties != nil {
+			return "map[string]" + g.goType(s.AdditionalProperties, name+"Value")
+		}
+		if len(s.Properties) != 0 {
+			g.namedType(name, s)
+			return name
+		}
+	}
+	return "json.RawMessage"
+}
+
+func (g *goGenerator) namedType(name string, s *rest.Schema) {
+	if s.Type != "object" || len(s.Properties) == 0 {
+		t := g.goType(s, name+"Value")
+		fmt.Fprintf(&g.types, "\n// %s is generated from the schema %s\ntype %s %s\n", name, name, name, t)
+		return
+	}
+	var fields bytes.Buffer
+	for _, property := range sortedKeys(s.Properties) {
+		tag := property
+		if contains(s.Required, property) == false {
+			tag += ",omitempty"
+		}
+		t := g.goType(s.Properties[property], name+exported(property))
+		if contains(s.Required, property) == false && g.isStructRef(s.Properties[property]) == true &&
+			strings.HasPrefix(t, "*") == false {
+			// the optional references are pointers, so that the recursive schemas (a user and its friends...) compile
+			t = "*" + t
+		}
+		fmt.Fprintf(&fields, "\t%s %s `json:\"%s\"`\n", exported(property), t, tag)
+	}
+	fmt.Fprintf(&g.types, "\n// %s is generated from the schema %s\ntype %s struct {\n%s}\n", name, name, name, fields.String())
+}
+
+// isStructRef tells whether s references a component schema generated as a struct
+func (g *goGenerator) isStructRef(s *rest.Schema) bool {
+	if s == nil || s.Ref == "" {
+		return false
+	}
+	target := g.schemas[s.Ref[strings.LastIndex(s.Ref, "/")+1:]]
+	return target != nil && target.Type == "object" && len(target.Properties) != 0
+}
+
+func (g *goGenerator) method(op operation) {
+	var args []string
+	var pathExpr []string
+	var queryParams []*rest.Parameter
+
+	literal := ""
+	for i, part := range strings.Split(op.path, "/") {
+		if i != 0 {
+			literal += "/"
+		}
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			arg := unexported(part[1 : len(part)-1])
+			args = append(args, arg+" string")
+			pathExpr = append(pathExpr, fmt.Sprintf("%q", literal), "url.PathEscape("+arg+")")
+			literal = ""
+		} else {
+			literal += part
+		}
+	}
+	if literal != "" {
+		pathExpr = append(pathExpr, fmt.Sprintf("%q", literal))
+	}
+	for _, param := range op.params {
+		if param.In == "query" {
+			queryParams = append(queryParams, param)
+		}
+	}
+	body := "nil"
+	if op.body != nil {
+		args = append(args, "body "+g.goType(op.body, op.name+"Request"))
+		body = "body"
+	}
+	if len(queryParams) != 0 {
+		args = append(args, "query url.Values")
+	}
+
+	fmt.Fprintf(&g.methods, "\n// %s calls %s %s\n", op.name, op.method, op.path)
+	query := "nil"
+	if len(queryParams) != 0 {
+		query = "query"
+		var names []string
+		for _, param := range queryParams {
+			names = append(names, param.Name)
+		}
+		fmt.Fprintf(&g.methods, "// The query parameters are: %s\n", strings.Join(names, ", "))
+	}
+	path := strings.Join(pathExpr, " + ")
+	if op.response == nil {
+		fmt.Fprintf(&g.methods, "func (c *Client) %s(%s) error {\n", op.name, strings.Join(append([]string{"ctx context.Context"}, args...), ", "))
+		fmt.Fprintf(&g.methods, "\treturn c.do(ctx, %q, %s, %s, %s, nil)\n}\n", op.method, path, query, body)
+		return
+	}
+	result := g.goType(op.response, op.name+"Response")
+	fmt.Fprintf(&g.methods, "func (c *Client) %s(%s) (%s, error) {\n", op.name, strings.Join(append([]string{"ctx context.Context"}, args...), ", "), result)
+	fmt.Fprintf(&g.methods, "\tvar result %s\n", result)
+	fmt.Fprintf(&g.methods, "\terr := c.do(ctx, %q, %s, %s, %s, &result)\n", op.method, path, query, body)
+	fmt.Fprintf(&g.methods, "\treturn result, err\n}\n")
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	"github.com/konek/rest"
+)
+
+func TestGenerateGoRecursive(t *testing.T) {
+	ref := func(name string) *rest.Schema {
+		return &rest.Schema{Ref: "#/components/schemas/" + name}
+	}
+	tests := []struct {
+		name    string
+		schemas map[string]*rest.Schema
+		field   string
+	}{
+		{"self reference", map[string]*rest.Schema{
+			"User": {Type: "object", Properties: map[string]*rest.Schema{
+				"name":   {Type: "string"},
+				"friend": ref("User"),
+			}},
+		}, "Friend *User"},
+		{"mutual references", map[string]*rest.Schema{
+			"Article": {Type: "object", Properties: map[string]*rest.Schema{
+				"author": ref("Person"),
+			}},
+			"Person": {Type: "object", Required: []string{"latest"}, Properties: map[string]*rest.Schema{
+				"latest":   ref("Article"),
+				"articles": {Type: "array", Items: ref("Article")},
+			}},
+		}, "Author *Person"},
+		{"nullable reference", map[string]*rest.Schema{
+			"Node": {Type: "object", Properties: map[string]*rest.Schema{
+				"parent": {Ref: "#/components/schemas/Node", Nullable: true},
+			}},
+		}, "Parent *Node"},
+	}
+	for _, test := range tests {
+		doc := &rest.OpenAPI{
+			Info:       rest.OpenAPIInfo{Title: "test", Version: "1"},
+			Components: &rest.Components{Schemas: test.schemas},
+		}
+		src, err := generateGo(doc, "client")
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		if strings.Contains(strings.Join(strings.Fields(string(src)), " "), test.field) == false {
+			t.Errorf("%s: no field %q in\n%s", test.name, test.field, src)
+		}
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, "client.go", src, 0)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+		if _, err = conf.Check("client", fset, []*ast.File{file}, nil); err != nil {
+			t.Errorf("%s: %s\n%s", test.name, err, src)
+		}
+	}
+}
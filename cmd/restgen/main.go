@@ -0,0 +1,217 @@
+// Command restgen generates a typed client from the OpenAPI document of a rest.Router (see Router.OpenAPI), so that
+// the consumers of an API stay in sync with its server.
+//
+//	restgen -in openapi.json -pkg client -out client/client.go
+//	restgen -in http://localhost:8081/openapi.json -lang ts -out client.ts
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/konek/rest"
+)
+
+var (
+	in   = flag.String("in", "openapi.json", "OpenAPI document, file or URL")
+	out  = flag.String("out", "", "output file, stdout if empty")
+	lang = flag.String("lang", "go", "language of the client: go or ts")
+	pkg  = flag.String("pkg", "client", "package name of the Go client")
+)
+
+func main() {
+	flag.Parse()
+
+	doc, err := load(*in)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "restgen: failed to load document:", err)
+		os.Exit(1)
+	}
+	var code []byte
+	if *lang == "go" {
+		code, err = generateGo(doc, *pkg)
+	} else if *lang == "ts" {
+		code, err = generateTS(doc)
+	} else {
+		err = fmt.Errorf("unknown language %q", *lang)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "restgen:", err)
+		os.Exit(1)
+	}
+	if *out == "" {
+		_, err = os.Stdout.Write(code)
+	} else {
+		err = ioutil.WriteFile(*out, code, 0644)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "restgen: failed to write client:", err)
+		os.Exit(1)
+	}
+}
+
+func load(location string) (*rest.OpenAPI, error) {
+	var r io.ReadCloser
+	var err error
+
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		var resp *http.Response
+		resp, err = http.Get(location)
+		if err == nil && resp.StatusCode != 200 {
+			resp.Body.Close()
+			err = fmt.Errorf("%s replied %s", location, resp.Status)
+		}
+		if resp != nil {
+			r = resp.Body
+		}
+	} else {
+		r, err = os.Open(location)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return rest.LoadOpenAPI(r)
+}
+
+// operation is an operation of the document, with the names used by the generators
+type operation struct {
+	name     string
+	method   string
+	path     string
+	params   []*rest.Parameter
+	body     *rest.Schema
+	response *rest.Schema
+}
+
+// operations lists the operations of the document, sorted by name
+func operations(doc *rest.OpenAPI) []operation {
+	var ops []operation
+
+	for path, item := range doc.Paths {
+		for _, method := range []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"} {
+			op := itemOperation(item, method)
+			if op == nil {
+				continue
+			}
+			o := operation{
+				name:   op.OperationID,
+				method: method,
+				path:   path,
+				params: append(append([]*rest.Parameter{}, item.Parameters...), op.Parameters...),
+			}
+			if o.name == "" {
+				o.name = strings.ToLower(method) + " " + path
+			}
+			o.name = exported(o.name)
+			if op.RequestBody != nil {
+				o.body = jsonSchema(op.RequestBody.Content)
+			}
+			for _, status := range []string{"200", "201", "202", "2XX", "default"} {
+				if response, ok := op.Responses[status]; ok == true {
+					o.response = jsonSchema(response.Content)
+					break
+				}
+			}
+			ops = append(ops, o)
+		}
+	}
+	sort.Slice(ops, func(i, j int) bool {
+		return ops[i].name < ops[j].name
+	})
+	return ops
+}
+
+func itemOperation(item *rest.PathItem, method string) *rest.Operation {
+	switch method {
+	case "GET":
+		return item.Get
+	case "HEAD":
+		return item.Head
+	case "POST":
+		return item.Post
+	case "PUT":
+		return item.Put
+	case "PATCH":
+		return item.Patch
+	case "DELETE":
+		return item.Delete
+	case "OPTIONS":
+		return item.Options
+	}
+	return nil
+}
+
+func jsonSchema(content map[string]*rest.MediaType) *rest.Schema {
+	for ctype, media := range content {
+		if ctype == "application/json" || strings.HasSuffix(ctype, "+json") {
+			return media.Schema
+		}
+	}
+	return nil
+}
+
+// exported converts an identifier like "GET /users/{id}" or "list_users" to "GetUsersID" or "ListUsers"
+func exported(name string) string {
+	var b strings.Builder
+
+	upper := true
+	for _, c := range name {
+		if unicode.IsLetter(c) == false && unicode.IsDigit(c) == false {
+			upper = true
+			continue
+		}
+		if upper == true {
+			c = unicode.ToUpper(c)
+			upper = false
+		} else if b.Len() == 0 {
+			c = unicode.ToUpper(c)
+		}
+		b.WriteRune(c)
+	}
+	s := b.String()
+	if s == "" || unicode.IsDigit(rune(s[0])) {
+		s = "X" + s
+	}
+	s = strings.Replace(s, "Id", "ID", -1)
+	return s
+}
+
+// unexported converts an identifier to a lower camel case one
+func unexported(name string) string {
+	s := exported(name)
+	for i, c := range s {
+		if unicode.IsLower(c) {
+			if i > 1 {
+				i--
+			}
+			return strings.ToLower(s[:i]) + s[i:]
+		}
+	}
+	return strings.ToLower(s)
+}
+
+func sortedKeys(m map[string]*rest.Schema) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
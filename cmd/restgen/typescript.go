@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/konek/rest"
+)
+
+// tsGenerator generates a TypeScript client based on fetch
+type tsGenerator struct {
+	types     bytes.Buffer
+	functions bytes.Buffer
+}
+
+func generateTS(doc *rest.OpenAPI) ([]byte, error) {
+	g := new(tsGenerator)
+	if doc.Components != nil {
+		for _, name := range sortedKeys(doc.Components.Schemas) {
+			fmt.Fprintf(&g.types, "\nexport type %s = %s;\n", exported(name), g.tsType(doc.Components.Schemas[name]))
+		}
+	}
+	for _, op := range operations(doc) {
+		g.function(op)
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by restgen. DO NOT EDIT.\n// Client of %s %s\n", doc.Info.Title, doc.Info.Version)
+	b.WriteString(`
+export class APIError extends Error {
+  constructor(public statusCode: number, message: string) {
+    super(message);
+  }
+}
+
+export class Client {
+  constructor(public baseURL: string, public headers: Record<string, string> = {}) {
+    this.baseURL = baseURL.replace(/\/$/, "");
+  }
+
+  async request<T>(method: string, path: string, query?: Record<string, string>, body?: unknown): Promise<T> {
+    let url = this.baseURL + path;
+    if (query && Object.keys(query).length) {
+      url += "?" + new URLSearchParams(query).toString();
+    }
+    const headers: Record<string, string> = { Accept: "application/json", ...this.headers };
+    if (body !== undefined) {
+      headers["Content-Type"] = "application/json";
+    }
+    const resp = await fetch(url, { method, headers, body: body === undefined ? undefined : JSON.stringify(body) });
+    if (resp.status >= 400) {
+      const envelope = await resp.json().catch(() => ({}));
+      throw new APIError(resp.status, envelope.Message || resp.statusText);
+    }
+    const text = await resp.text();
+    return (text ? JSON.parse(text) : undefined) as T;
+  }
+`)
+	b.Write(g.functions.Bytes())
+	b.WriteString("}\n")
+	b.Write(g.types.Bytes())
+	return b.Bytes(), nil
+}
+
+func (g *tsGenerator) tsType(s *rest.Schema) string {
+	if s == nil {
+		return "unknown"
+	}
+	t := g.baseType(s)
+	if s.Nullable == true {
+		t += " | null"
+	}
+	return t
+}
+
+func (g *tsGenerator) baseType(s *rest.Schema) string {
+	if s.Ref != "" {
+		return exported(s.Ref[strings.LastIndex(s.Ref, "/")+1:])
+	}
+	if len(s.Enum) != 0 {
+		var values []string
+		for _, value := range s.Enum {
+			values = append(values, fmt.Sprintf("%q", fmt.Sprint(value)))
+		}
+		if s.Type == "string" {
+			return strings.Join(values, " | ")
+		}
+	}
+	switch s.Type {
+	case "string":
+		return "string"
+	case "integer", "number":
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "array":
+		return "Array<" + g.tsType(s.Items) + ">"
+	case "object":
+		if len(s.Properties) == 0 && s.AdditionalProperties != nil {
+			return "Record<string, " + g.tsType(s.AdditionalProperties) + ">"
+		}
+		var fields []string
+		for _, property := range sortedKeys(s.Properties) {
+			optional := "?"
+			if contains(s.Required, property) == true {
+				optional = ""
+			}
+			fields = append(fields, fmt.Sprintf("%q%s: %s", property, optional, g.tsType(s.Properties[property])))
+		}
+		return "{ " + strings.Join(fields, "; ") + " }"
+	}
+	return "unknown"
+}
+
+func (g *tsGenerator) function(op operation) {
+	var args []string
+	var parts []string
+
+	for _, part := range strings.Split(op.path, "/") {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			arg := unexported(part[1 : len(part)-1])
+			args = append(args, arg+": string")
+			parts = append(parts, "${encodeURIComponent("+arg+")}")
+		} else {
+			parts = append(parts, part)
+		}
+	}
+	body := "undefined"
+	if op.body != nil {
+		args = append(args, "body: "+g.tsType(op.body))
+		body = "body"
+	}
+	query := "undefined"
+	for _, param := range op.params {
+		if param.In == "query" {
+			args = append(args, "query: Record<string, string> = {}")
+			query = "query"
+			break
+		}
+	}
+	result := "void"
+	if op.response != nil {
+		result = g.tsType(op.response)
+	}
+	fmt.Fprintf(&g.functions, "\n  // %s %s\n", op.method, op.path)
+	fmt.Fprintf(&g.functions, "  %s(%s): Promise<%s> {\n", unexported(op.name), strings.Join(args, ", "), result)
+	fmt.Fprintf(&g.functions, "    return this.request<%s>(%q, `%s`, %s, %s);\n  }\n", result, op.method, strings.Join(parts, "/"), query, body)
+}
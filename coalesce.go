@@ -0,0 +1,111 @@
+package rest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// Coalesce makes the concurrent identical GET and HEAD requests of the route share one execution of the controller,
+// in order to protect the expensive read routes during traffic spikes. The middlewares of the route run for every
+// request, only the controller call is shared.
+// The requests are identical if they have the same URL and the same Accept, Accept-Language, Authorization, Cookie
+// and X-Api-Key headers and client certificate. The routes whose responses depend on anything else (another header,
+// the client IP...) must set the key with CoalesceBy. The streamed responses are not shared. The controller runs
+// with the context of the first request: if its client leaves, the requests sharing its execution may be canceled as
+// well.
+func (rt *Route) Coalesce() *Route {
+	return rt.CoalesceBy(nil)
+}
+
+// CoalesceBy makes the route coalesce its identical requests as Coalesce does, the requests being identical if key
+// returns the same value for them. The default key is used if key is nil.
+func (rt *Route) CoalesceBy(key func(r *http.Request) string) *Route {
+	return rt.update(func(options *RouteOptions) {
+		options.Coalesce = true
+		options.CoalesceKey = key
+	})
+}
+
+// flight is an execution of the controller shared by identical requests
+type flight struct {
+	done  chan struct{}
+	resp  interface{}
+	err   error
+	panic interface{}
+}
+
+// coalesceKey identifies the identical requests
+func coalesceKey(r *http.Request) string {
+	var cert string
+	if r.TLS != nil && len(r.TLS.PeerCertificates) != 0 {
+		sum := sha256.Sum256(r.TLS.PeerCertificates[0].Raw)
+		cert = hex.EncodeToString(sum[:])
+	}
+	return strings.Join([]string{
+		r.URL.RequestURI(),
+		r.Header.Get("Accept"),
+		r.Header.Get("Accept-Language"),
+		r.Header.Get("Authorization"),
+		r.Header.Get("Cookie"),
+		r.Header.Get("X-Api-Key"),
+		cert,
+	}, "\n")
+}
+
+// coalesced serves the identical requests with a single call of next, key identifying them
+func (rt *Route) coalesced(key func(r *http.Request) string, next Controller) Controller {
+	if key == nil {
+		key = coalesceKey
+	}
+	return func(r *http.Request, p Params) (interface{}, error) {
+		if r.Method != "GET" && r.Method != "HEAD" {
+			return next(r, p)
+		}
+
+		k := r.Method + "\n" + key(r)
+		rt.mu.Lock()
+		f, shared := rt.flights[k]
+		if shared == false {
+			f = &flight{
+				done: make(chan struct{}),
+			}
+			if rt.flights == nil {
+				rt.flights = make(map[string]*flight)
+			}
+			rt.flights[k] = f
+		}
+		rt.mu.Unlock()
+
+		if shared == false {
+			func() {
+				defer func() {
+					f.panic = recover()
+					rt.mu.Lock()
+					delete(rt.flights, k)
+					rt.mu.Unlock()
+					close(f.done)
+				}()
+				f.resp, f.err = next(r, p)
+			}()
+		} else {
+			if router := routerFromRequest(r); router != nil {
+				router.incr("rest.requests.coalesced", map[string]string{"method": rt.method, "route": rt.path})
+			}
+			select {
+			case <-f.done:
+			case <-r.Context().Done():
+				return nil, NewAPIError(503, "the request was canceled")
+			}
+		}
+		if f.panic != nil {
+			panic(f.panic)
+		}
+		if _, ok := f.resp.(RespStream); ok == true && shared == true {
+			// a stream can only be read once
+			return next(r, p)
+		}
+		return f.resp, f.err
+	}
+}
@@ -0,0 +1,103 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCoalesce(t *testing.T) {
+	var calls int32
+
+	release := make(chan struct{})
+	deny := func(next Controller) Controller {
+		return func(r *http.Request, p Params) (interface{}, error) {
+			if r.Header.Get("X-Deny") != "" {
+				return nil, NewAPIError(403, "forbidden")
+			}
+			return next(r, p)
+		}
+	}
+	router := New()
+	router.GET("/report", func(r *http.Request, p Params) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return map[string]string{"user": r.Header.Get("Authorization")}, nil
+	}).Coalesce().Use(deny)
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		code    int
+		body    string
+	}{
+		{"leader", map[string]string{"Authorization": "a"}, 200, `{"user":"a"}`},
+		{"follower", map[string]string{"Authorization": "a"}, 200, `{"user":"a"}`},
+		{"other principal", map[string]string{"Authorization": "b"}, 200, `{"user":"b"}`},
+		{"denied", map[string]string{"Authorization": "a", "X-Deny": "1"}, 403, ""},
+	}
+	var wg sync.WaitGroup
+	results := make([]*httptest.ResponseRecorder, len(tests))
+	for i, test := range tests {
+		wg.Add(1)
+		go func(i int, headers map[string]string) {
+			defer wg.Done()
+			req := httptest.NewRequest("GET", "/report", nil)
+			req.Header.Set("Accept", "application/json")
+			for name, value := range headers {
+				req.Header.Set(name, value)
+			}
+			results[i] = httptest.NewRecorder()
+			router.ServeHTTP(results[i], req)
+		}(i, test.headers)
+		time.Sleep(20 * time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	for i, test := range tests {
+		if results[i].Code != test.code {
+			t.Errorf("%s: got %d, expected %d", test.name, results[i].Code, test.code)
+		}
+		if test.body != "" && results[i].Body.String() != test.body {
+			t.Errorf("%s: got %s, expected %s", test.name, results[i].Body.String(), test.body)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("the controller ran %d times, expected 2", calls)
+	}
+}
+
+func TestCoalesceBy(t *testing.T) {
+	var calls int32
+
+	release := make(chan struct{})
+	router := New()
+	router.GET("/report", func(r *http.Request, p Params) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return "ok", nil
+	}).CoalesceBy(func(r *http.Request) string {
+		return r.Header.Get("X-Tenant")
+	})
+
+	var wg sync.WaitGroup
+	for _, tenant := range []string{"a", "a", "b"} {
+		wg.Add(1)
+		go func(tenant string) {
+			defer wg.Done()
+			req := httptest.NewRequest("GET", "/report", nil)
+			req.Header.Set("X-Tenant", tenant)
+			router.ServeHTTP(httptest.NewRecorder(), req)
+		}(tenant)
+		time.Sleep(20 * time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+	if calls != 2 {
+		t.Errorf("the controller ran %d times, expected 2", calls)
+	}
+}
@@ -0,0 +1,215 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Lenient makes Parse and ParseQuery coerce the strings into the numbers and booleans expected by the fields
+// ("42" into an int, "true" into a bool), as sent by some JavaScript clients. The values which can't be coerced
+// are rejected with a 400 listing the fields in error.
+func (rt *Route) Lenient() *Route {
+	return rt.update(func(options *RouteOptions) {
+		options.Lenient = true
+	})
+}
+
+// isLenient returns whether the route of the request is lenient
+func isLenient(r *http.Request) bool {
+	route := stateFromRequest(r).route
+	return route != nil && route.Options().Lenient == true
+}
+
+// ParseQuery parses the query parameters into v, a pointer to a struct. The parameters are matched to the fields
+// by name, regardless of case. The string, time.Time and time.Duration fields are set, and the numbers and booleans
+// on the lenient routes. The fields with an enum tag are checked as by Parse.
+func ParseQuery(r *http.Request, v interface{}) error {
+	err := parseForm(r.URL.Query(), v, coercionOf(r))
+	if err != nil {
+		return err
+	}
+	return checkEnums(v)
+}
+
+// coercionOf returns how the values of the request are coerced
+func coercionOf(r *http.Request) coercion {
+	return coercion{
+		lenient: isLenient(r),
+		layout:  timeLayout(r),
+	}
+}
+
+var jsonUnmarshaler = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// coercion sets how the values are coerced
+type coercion struct {
+	// lenient converts the strings into numbers and booleans
+	lenient bool
+	// layout is the time layout of the router, see SetTimeLayout
+	layout string
+}
+
+// coerceJSON converts the values of the JSON document chunk into the values expected by the fields of t by
+// encoding/json: the times and durations, and the numbers and booleans if lenient. The errors are added to errs.
+func coerceJSON(chunk []byte, t reflect.Type, c coercion, errs *[]FieldError) ([]byte, error) {
+	var doc interface{}
+
+	decoder := json.NewDecoder(bytes.NewReader(chunk))
+	decoder.UseNumber()
+	err := decoder.Decode(&doc)
+	if err != nil {
+		return nil, err
+	}
+	doc = c.value(doc, t, "", errs)
+	return json.Marshal(doc)
+}
+
+func (c coercion) value(value interface{}, t reflect.Type, path string, errs *[]FieldError) interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType || t == durationType {
+		coerced, err := coerceTime(value, t, c.layout)
+		if err != nil {
+			*errs = append(*errs, FieldError{Field: path, Message: err.Error()})
+			return value
+		}
+		return coerced
+	}
+	if reflect.PtrTo(t).Implements(jsonUnmarshaler) == true {
+		return value
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := value.(map[string]interface{})
+		if ok == false {
+			return value
+		}
+		for key, v := range obj {
+			if field, found := jsonField(t, key); found == true {
+				obj[key] = c.value(v, field.Type, joinPath(path, key), errs)
+			}
+		}
+	case reflect.Map:
+		if obj, ok := value.(map[string]interface{}); ok == true {
+			for key, v := range obj {
+				obj[key] = c.value(v, t.Elem(), joinPath(path, key), errs)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if list, ok := value.([]interface{}); ok == true {
+			for i, v := range list {
+				list[i] = c.value(v, t.Elem(), path+"["+strconv.Itoa(i)+"]", errs)
+			}
+		}
+	default:
+		if s, ok := value.(string); ok == true && c.lenient == true {
+			coerced, err := coerceString(s, t)
+			if err != nil {
+				*errs = append(*errs, FieldError{Field: path, Message: err.Error()})
+				return value
+			}
+			return coerced
+		}
+	}
+	return value
+}
+
+// coerceString converts s for a field of type t, it is returned unchanged for the kinds other than the numbers and
+// the booleans. The numbers overflowing t are rejected.
+func coerceString(s string, t reflect.Type) (interface{}, error) {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an integer", s)
+		}
+		if reflect.Zero(t).OverflowInt(n) == true {
+			return nil, fmt.Errorf("%q is out of range", s)
+		}
+		return n, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(strings.TrimSpace(s), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a positive integer", s)
+		}
+		if reflect.Zero(t).OverflowUint(n) == true {
+			return nil, fmt.Errorf("%q is out of range", s)
+		}
+		return n, nil
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", s)
+		}
+		if reflect.Zero(t).OverflowFloat(f) == true {
+			return nil, fmt.Errorf("%q is out of range", s)
+		}
+		return f, nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(s))
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a boolean", s)
+		}
+		return b, nil
+	}
+	return s, nil
+}
+
+// jsonField returns the field of t encoded under key by encoding/json
+func jsonField(t reflect.Type, key string) (reflect.StructField, bool) {
+	var fallback *reflect.StructField
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && field.Anonymous == false {
+			continue
+		}
+		name := field.Name
+		if tag := field.Tag.Get("json"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+			if comma := strings.Index(tag, ","); comma != -1 {
+				tag = tag[:comma]
+			}
+			if tag != "" {
+				name = tag
+			}
+		}
+		if field.Anonymous == true && name == field.Name {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if embedded, ok := jsonField(ft, key); ok == true {
+					return embedded, true
+				}
+				continue
+			}
+		}
+		if name == key {
+			return field, true
+		}
+		if fallback == nil && strings.EqualFold(name, key) == true {
+			fallback = &field
+		}
+	}
+	if fallback != nil {
+		return *fallback, true
+	}
+	return reflect.StructField{}, false
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,47 @@
+package rest
+
+import (
+	"net/url"
+	"testing"
+)
+
+type coerceBody struct {
+	N int8
+	U uint8
+	F float32
+	I int64
+	B bool
+}
+
+func TestLenientCoercion(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		json     string
+		ok       bool
+		expected coerceBody
+	}{
+		{"in range", "n=-128&u=255&f=1.5&i=300&b=true", `{"N": "-128", "U": "255", "F": "1.5", "I": "300", "B": "true"}`, true,
+			coerceBody{N: -128, U: 255, F: 1.5, I: 300, B: true}},
+		{"int8 overflow", "n=300", `{"N": "300"}`, false, coerceBody{}},
+		{"int8 underflow", "n=-129", `{"N": "-129"}`, false, coerceBody{}},
+		{"uint8 overflow", "u=256", `{"U": "256"}`, false, coerceBody{}},
+		{"float32 overflow", "f=1e39", `{"F": "1e39"}`, false, coerceBody{}},
+		{"not a number", "n=x", `{"N": "x"}`, false, coerceBody{}},
+	}
+	for _, test := range tests {
+		var fromForm, fromJSON coerceBody
+		form, _ := url.ParseQuery(test.query)
+		err := decodeForm(form, &fromForm, coercion{lenient: true}, DefaultParseLimits)
+		if (err == nil) != test.ok || (test.ok == true && fromForm != test.expected) {
+			t.Errorf("%s: form got %+v (%v)", test.name, fromForm, err)
+		}
+		if _, ok := err.(ValidationError); err != nil && ok == false {
+			t.Errorf("%s: form got %T", test.name, err)
+		}
+		err = decodeBody(formatJSON, []byte(test.json), &fromJSON, coercion{lenient: true}, DefaultParseLimits, DefaultXMLOptions)
+		if (err == nil) != test.ok || (test.ok == true && fromJSON != test.expected) {
+			t.Errorf("%s: JSON got %+v (%v)", test.name, fromJSON, err)
+		}
+	}
+}
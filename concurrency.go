@@ -0,0 +1,99 @@
+package rest
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// ConcurrencyLimit limits the number of requests of a route served at the same time
+type ConcurrencyLimit struct {
+	// Max is the maximum number of requests served at the same time
+	Max int
+	// Queue is the maximum number of requests waiting for a slot, the others are rejected right away
+	Queue int
+	// QueueTimeout is the maximum time spent waiting for a slot, 0 to wait until the client leaves
+	QueueTimeout time.Duration
+	// RetryAfter is sent in the Retry-After header of the rejected requests, 0 for no header
+	RetryAfter time.Duration
+}
+
+// WithConcurrencyLimit limits the number of requests of the route served at the same time, in order to protect the
+// expensive routes (reports, exports...) from overload. The requests which can't be served are rejected with a 503.
+func (rt *Route) WithConcurrencyLimit(limit ConcurrencyLimit) *Route {
+	return rt.update(func(options *RouteOptions) {
+		options.Concurrency = &limit
+	})
+}
+
+// concurrencyLimiter is the semaphore of a route
+type concurrencyLimiter struct {
+	ConcurrencyLimit
+	slots  chan struct{}
+	queued int64
+}
+
+// limiter returns the semaphore matching limit, it is created again when the limit of the route changes.
+// The requests being served release the slots of the semaphore they acquired.
+func (rt *Route) limiter(limit ConcurrencyLimit) *concurrencyLimiter {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	if rt.sem == nil || rt.sem.ConcurrencyLimit != limit {
+		rt.sem = &concurrencyLimiter{
+			ConcurrencyLimit: limit,
+			slots:            make(chan struct{}, limit.Max),
+		}
+	}
+	return rt.sem
+}
+
+// acquire waits for a slot, it returns false if the request is rejected
+func (l *concurrencyLimiter) acquire(r *http.Request) bool {
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+	}
+	if atomic.AddInt64(&l.queued, 1) > int64(l.Queue) {
+		atomic.AddInt64(&l.queued, -1)
+		return false
+	}
+	defer atomic.AddInt64(&l.queued, -1)
+
+	var timeout <-chan time.Time
+	if l.QueueTimeout > 0 {
+		timer := time.NewTimer(l.QueueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	case <-timeout:
+		return false
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+func (l *concurrencyLimiter) release() {
+	<-l.slots
+}
+
+// limitConcurrency runs next once the request gets a slot of the semaphore, or rejects it with a 503
+func limitConcurrency(l *concurrencyLimiter, next Controller) Controller {
+	return func(r *http.Request, p Params) (interface{}, error) {
+		if l.acquire(r) == false {
+			state := stateFromRequest(r)
+			if l.RetryAfter > 0 && state.w != nil {
+				state.w.Header().Set("Retry-After", seconds(l.RetryAfter))
+			}
+			if router := routerFromRequest(r); router != nil {
+				router.incr("rest.requests.rejected", state.tags())
+			}
+			return nil, NewAPIError(503, "too many requests in progress, please retry later")
+		}
+		defer l.release()
+		return next(r, p)
+	}
+}
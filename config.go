@@ -0,0 +1,249 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Duration is a time.Duration read from the configuration as a string ("30s", "1m30s") or a number of seconds
+type Duration time.Duration
+
+// UnmarshalJSON reads a duration string or a number of seconds
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		return d.set(s)
+	}
+	var n float64
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("invalid duration %s", data)
+	}
+	*d = Duration(n * float64(time.Second))
+	return nil
+}
+
+// MarshalJSON writes the duration as a string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) set(s string) error {
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		*d = Duration(n * float64(time.Second))
+		return nil
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q", s)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// CORSConfig sets the Cross-Origin Resource Sharing headers of the router
+type CORSConfig struct {
+	// AllowedOrigins are the origins allowed to call the API, "*" for all of them (without AllowCredentials).
+	// CORS is disabled if empty.
+	AllowedOrigins []string `json:"allowed_origins" env:"CORS_ALLOWED_ORIGINS"`
+	// AllowedMethods are the methods allowed in the preflight requests, GET, HEAD, POST, PUT and DELETE if empty
+	AllowedMethods []string `json:"allowed_methods" env:"CORS_ALLOWED_METHODS"`
+	// AllowedHeaders are the headers allowed in the preflight requests, the requested ones if empty
+	AllowedHeaders   []string `json:"allowed_headers" env:"CORS_ALLOWED_HEADERS"`
+	ExposedHeaders   []string `json:"exposed_headers" env:"CORS_EXPOSED_HEADERS"`
+	AllowCredentials bool     `json:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS"`
+	// MaxAge is the duration the preflight responses can be cached by the clients
+	MaxAge Duration `json:"max_age" env:"CORS_MAX_AGE"`
+}
+
+// TLSConfig sets the certificate the server helpers (ListenAndServe...) serve HTTPS with
+type TLSConfig struct {
+	CertFile string `json:"cert_file" env:"TLS_CERT_FILE"`
+	KeyFile  string `json:"key_file" env:"TLS_KEY_FILE"`
+}
+
+// Config is the deployment configuration of a router, see NewWithConfig. It is read from a JSON file with
+// LoadConfig, and from the environment with LoadEnv (REST_ADDR, REST_LOG_LEVEL, REST_CORS_ALLOWED_ORIGINS...).
+type Config struct {
+	// Addr is the address ListenAndServe listens on when called with an empty address
+	Addr string `json:"addr" env:"ADDR"`
+	// Dev enables the development mode
+	Dev bool `json:"dev" env:"DEV"`
+	// LogLevel is the minimum level of the logs: debug, info, warn or error. It is info by default, debug in development
+	// mode.
+	LogLevel string `json:"log_level" env:"LOG_LEVEL"`
+
+	// RequestTimeout is the timeout of the routes which don't set their own, 0 for no timeout
+	RequestTimeout Duration `json:"request_timeout" env:"REQUEST_TIMEOUT"`
+	// ReadTimeout, WriteTimeout and IdleTimeout are the timeouts of the http.Server of the server helpers
+	ReadTimeout     Duration `json:"read_timeout" env:"READ_TIMEOUT"`
+	WriteTimeout    Duration `json:"write_timeout" env:"WRITE_TIMEOUT"`
+	IdleTimeout     Duration `json:"idle_timeout" env:"IDLE_TIMEOUT"`
+	ShutdownTimeout Duration `json:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+
+	// MaxBodySize is the maximum request body size of the routes which don't set their own, 0 for no limit
+	MaxBodySize    int64 `json:"max_body_size" env:"MAX_BODY_SIZE"`
+	MaxHeaderBytes int   `json:"max_header_bytes" env:"MAX_HEADER_BYTES"`
+	// MaxResponseSize is the maximum encoded response size of the routes which don't set their own, the larger
+	// responses are replaced by a 500. 0 for no limit.
+	MaxResponseSize int64 `json:"max_response_size" env:"MAX_RESPONSE_SIZE"`
+
+	CORS CORSConfig `json:"cors"`
+	TLS  TLSConfig  `json:"tls"`
+}
+
+// DefaultConfig returns the default configuration, which LoadConfig starts from. It matches the behavior of a
+// router created by New.
+func DefaultConfig() Config {
+	return Config{
+		ShutdownTimeout: Duration(30 * time.Second),
+	}
+}
+
+// LoadConfig reads a JSON configuration, the missing settings keep the values of DefaultConfig:
+//
+//	{
+//		"addr": ":8080",
+//		"log_level": "warn",
+//		"request_timeout": "10s",
+//		"cors": {"allowed_origins": ["https://app.example.com"]}
+//	}
+func LoadConfig(reader io.Reader) (Config, error) {
+	cfg := DefaultConfig()
+	err := json.NewDecoder(reader).Decode(&cfg)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read configuration: %s", err)
+	}
+	return cfg, nil
+}
+
+// LoadEnv overwrites the settings set in the environment, the name of the variables being REST_ followed by the
+// env tag of the field (REST_LOG_LEVEL, REST_CORS_ALLOWED_ORIGINS...). Lists are comma separated.
+func (c *Config) LoadEnv() error {
+	return loadEnv(reflect.ValueOf(c).Elem(), "REST_")
+}
+
+func loadEnv(v reflect.Value, prefix string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			if err := loadEnv(field, prefix); err != nil {
+				return err
+			}
+			continue
+		}
+		name := t.Field(i).Tag.Get("env")
+		if name == "" {
+			continue
+		}
+		value, ok := os.LookupEnv(prefix + name)
+		if ok == false {
+			continue
+		}
+		if err := setConfigValue(field, value); err != nil {
+			return fmt.Errorf("%s%s: %s", prefix, name, err)
+		}
+	}
+	return nil
+}
+
+func setConfigValue(field reflect.Value, value string) error {
+	if d, ok := field.Addr().Interface().(*Duration); ok == true {
+		return d.set(value)
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		field.SetInt(n)
+	case reflect.Slice:
+		var values []string
+		for _, s := range strings.Split(value, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				values = append(values, s)
+			}
+		}
+		field.Set(reflect.ValueOf(values))
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+// ParseLevel returns the level named s: debug, info, warn or error
+func ParseLevel(s string) (Level, error) {
+	for l := LevelDebug; l <= LevelError; l++ {
+		if strings.EqualFold(s, l.String()) == true {
+			return l, nil
+		}
+	}
+	return LevelInfo, fmt.Errorf("unknown log level %q", s)
+}
+
+// NewWithConfig creates a new router configured by cfg
+func NewWithConfig(cfg Config) (*Router, error) {
+	level := LevelInfo
+	if cfg.LogLevel != "" {
+		var err error
+		level, err = ParseLevel(cfg.LogLevel)
+		if err != nil {
+			return nil, err
+		}
+	} else if cfg.Dev == true {
+		level = LevelDebug
+	}
+	if (cfg.TLS.CertFile == "") != (cfg.TLS.KeyFile == "") {
+		return nil, fmt.Errorf("the TLS certificate and key files must be set together")
+	}
+	if cfg.CORS.AllowCredentials == true {
+		for _, origin := range cfg.CORS.AllowedOrigins {
+			if origin == "*" {
+				return nil, fmt.Errorf("the CORS credentials can't be allowed to all the origins")
+			}
+		}
+	}
+
+	r := New()
+	r.config = cfg
+	r.logger = StdLogger{level}
+	r.shutdownTimeout = time.Duration(cfg.ShutdownTimeout)
+	if len(cfg.CORS.AllowedOrigins) != 0 {
+		r.cors = newCORS(cfg.CORS)
+	}
+	return r, nil
+}
+
+// server returns an http.Server with the timeouts and limits of the configuration of the router
+func (r *Router) server(addr string) *http.Server {
+	r.mu.RLock()
+	cfg := r.config
+	r.mu.RUnlock()
+	if addr == "" {
+		addr = cfg.Addr
+	}
+	return &http.Server{
+		Addr:           addr,
+		Handler:        r,
+		ReadTimeout:    time.Duration(cfg.ReadTimeout),
+		WriteTimeout:   time.Duration(cfg.WriteTimeout),
+		IdleTimeout:    time.Duration(cfg.IdleTimeout),
+		MaxHeaderBytes: cfg.MaxHeaderBytes,
+	}
+}
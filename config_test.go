@@ -0,0 +1,31 @@
+package rest
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConfigLogLevel(t *testing.T) {
+	tests := []struct {
+		config string
+		level  string
+	}{
+		{`{}`, "info"},
+		{`{"dev": true}`, "debug"},
+		{`{"dev": true, "log_level": "warn"}`, "warn"},
+		{`{"log_level": "error"}`, "error"},
+	}
+	for _, test := range tests {
+		cfg, err := LoadConfig(strings.NewReader(test.config))
+		if err != nil {
+			t.Fatalf("%s: %s", test.config, err)
+		}
+		r, err := NewWithConfig(cfg)
+		if err != nil {
+			t.Fatalf("%s: %s", test.config, err)
+		}
+		if level := r.logLevel(); level != test.level {
+			t.Errorf("%s: got %s, expected %s", test.config, level, test.level)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+type contextKey int
+
+const (
+	routerKey contextKey = iota
+	txKey
+	stateKey
+	auditKey
+	lambdaKey
+)
+
+// routerFromRequest returns the router serving the request, nil if the request was not dispatched by a Router
+func routerFromRequest(r *http.Request) *Router {
+	router, _ := r.Context().Value(routerKey).(*Router)
+	return router
+}
+
+func withRouter(r *http.Request, router *Router) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), routerKey, router))
+}
+
+// requestState is shared by the handler and the middlewares of a request, it allows the middlewares to change how
+// the handler writes the response
+type requestState struct {
+	jsonAPI bool
+	digest  bool
+	w       http.ResponseWriter
+	hinted  bool
+	route   *Route
+	logger  Logger
+	timing  requestTiming
+	// responseLimit is the maximum response size of the route
+	responseLimit responseLimit
+
+	eventsMu sync.Mutex
+	events   []Event
+}
+
+// stateFromRequest returns the state of the request, a throwaway state if the request was not dispatched by handler
+func stateFromRequest(r *http.Request) *requestState {
+	if state, ok := r.Context().Value(stateKey).(*requestState); ok == true {
+		return state
+	}
+	return new(requestState)
+}
+
+// detach returns a copy of the state for a controller which may outlive the response (see callWithTimeout), writing
+// to w. The changes of the copy are applied with merge once the controller returns in time.
+func (s *requestState) detach(w http.ResponseWriter) *requestState {
+	return &requestState{
+		jsonAPI:       s.jsonAPI,
+		digest:        s.digest,
+		w:             w,
+		hinted:        s.hinted,
+		route:         s.route,
+		logger:        s.logger,
+		timing:        requestTiming{start: s.timing.start},
+		responseLimit: s.responseLimit,
+	}
+}
+
+// merge applies the changes of a state returned by detach
+func (s *requestState) merge(detached *requestState) {
+	s.jsonAPI = detached.jsonAPI
+	s.digest = detached.digest
+	s.hinted = detached.hinted
+	s.logger = detached.logger
+	atomic.AddInt64(&s.timing.bind, atomic.LoadInt64(&detached.timing.bind))
+	detached.eventsMu.Lock()
+	events := detached.events
+	detached.eventsMu.Unlock()
+	s.eventsMu.Lock()
+	s.events = append(s.events, events...)
+	s.eventsMu.Unlock()
+}
+
+// tags returns the tags identifying the route of the request in the metrics
+func (s *requestState) tags() map[string]string {
+	if s.route == nil {
+		return map[string]string{}
+	}
+	return map[string]string{
+		"method": s.route.method,
+		"route":  s.route.path,
+	}
+}
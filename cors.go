@@ -0,0 +1,71 @@
+package rest
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// cors sets the CORS headers of the responses and replies to the preflight requests
+type cors struct {
+	CORSConfig
+	origins map[string]bool
+	any     bool
+}
+
+func newCORS(cfg CORSConfig) *cors {
+	c := &cors{
+		CORSConfig: cfg,
+		origins:    make(map[string]bool),
+	}
+	for _, origin := range cfg.AllowedOrigins {
+		if origin == "*" {
+			c.any = true
+		}
+		c.origins[strings.ToLower(origin)] = true
+	}
+	if len(c.AllowedMethods) == 0 {
+		c.AllowedMethods = []string{"GET", "HEAD", "POST", "PUT", "DELETE"}
+	}
+	return c
+}
+
+// serve sets the CORS headers, it returns true if the request was a preflight request and got its response.
+// Vary: Origin is set on every response, so that the caches don't serve a response without the CORS headers to an
+// allowed origin.
+func (c *cors) serve(w http.ResponseWriter, r *http.Request) bool {
+	addVary(w.Header(), "Origin")
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return false
+	}
+	if c.any == false && c.origins[strings.ToLower(origin)] == false {
+		return false
+	}
+	if c.any == true {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+	} else {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+	}
+	if c.AllowCredentials == true {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+
+	if r.Method != "OPTIONS" || r.Header.Get("Access-Control-Request-Method") == "" {
+		if len(c.ExposedHeaders) != 0 {
+			w.Header().Set("Access-Control-Expose-Headers", strings.Join(c.ExposedHeaders, ", "))
+		}
+		return false
+	}
+	w.Header().Set("Access-Control-Allow-Methods", strings.Join(c.AllowedMethods, ", "))
+	if len(c.AllowedHeaders) != 0 {
+		w.Header().Set("Access-Control-Allow-Headers", strings.Join(c.AllowedHeaders, ", "))
+	} else if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+		w.Header().Set("Access-Control-Allow-Headers", requested)
+	}
+	if c.MaxAge > 0 {
+		w.Header().Set("Access-Control-Max-Age", seconds(time.Duration(c.MaxAge)))
+	}
+	w.WriteHeader(204)
+	return true
+}
@@ -0,0 +1,76 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCORS(t *testing.T) {
+	router, err := NewWithConfig(Config{CORS: CORSConfig{
+		AllowedOrigins:   []string{"https://app.example.com"},
+		AllowCredentials: true,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	router.GET("/items", func(r *http.Request, p Params) (interface{}, error) {
+		return "ok", nil
+	})
+	any, err := NewWithConfig(Config{CORS: CORSConfig{AllowedOrigins: []string{"*"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	any.GET("/items", func(r *http.Request, p Params) (interface{}, error) {
+		return "ok", nil
+	})
+
+	tests := []struct {
+		name        string
+		router      *Router
+		method      string
+		origin      string
+		preflight   bool
+		code        int
+		allow       string
+		credentials string
+	}{
+		{"allowed", router, "GET", "https://app.example.com", false, 200, "https://app.example.com", "true"},
+		{"allowed case", router, "GET", "https://APP.example.com", false, 200, "https://APP.example.com", "true"},
+		{"other origin", router, "GET", "https://evil.example.com", false, 200, "", ""},
+		{"no origin", router, "GET", "", false, 200, "", ""},
+		{"preflight", router, "OPTIONS", "https://app.example.com", true, 204, "https://app.example.com", "true"},
+		{"any origin", any, "GET", "https://evil.example.com", false, 200, "*", ""},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, "/items", nil)
+		if test.origin != "" {
+			req.Header.Set("Origin", test.origin)
+		}
+		if test.preflight == true {
+			req.Header.Set("Access-Control-Request-Method", "GET")
+		}
+		w := httptest.NewRecorder()
+		test.router.ServeHTTP(w, req)
+		if w.Code != test.code {
+			t.Errorf("%s: got %d, expected %d", test.name, w.Code, test.code)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != test.allow {
+			t.Errorf("%s: got origin %q, expected %q", test.name, got, test.allow)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Credentials"); got != test.credentials {
+			t.Errorf("%s: got credentials %q, expected %q", test.name, got, test.credentials)
+		}
+		if strings.Contains(w.Header().Get("Vary"), "Origin") == false {
+			t.Errorf("%s: no Vary: Origin", test.name)
+		}
+	}
+}
+
+func TestCORSConfig(t *testing.T) {
+	_, err := NewWithConfig(Config{CORS: CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true}})
+	if err == nil {
+		t.Error("the credentials were allowed to all the origins")
+	}
+}
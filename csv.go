@@ -0,0 +1,123 @@
+package rest
+
+import (
+	"encoding"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"reflect"
+)
+
+// csvFlushRows is the number of rows after which the response is flushed to the client
+const csvFlushRows = 100
+
+// csvEncodable tells whether data is a struct or a slice of structs, which are the values encodable as CSV. The nil
+// pointers are not encodable, they are output as JSON.
+func csvEncodable(data interface{}) bool {
+	val := reflect.ValueOf(data)
+	for val.Kind() == reflect.Ptr {
+		val = val.Elem()
+	}
+	if val.IsValid() == false {
+		return false
+	}
+	t := val.Type()
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+	}
+	return t.Kind() == reflect.Struct
+}
+
+// csvColumns returns the indexes of the encoded fields of t, with their header. The header is given by the csv tag,
+// the name of the field otherwise. Fields tagged with "-" are skipped.
+func csvColumns(t reflect.Type) ([]int, []string) {
+	var indexes []int
+	var headers []string
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := field.Tag.Get("csv")
+		if name == "-" {
+			continue
+		} else if name == "" {
+			name = field.Name
+		}
+		indexes = append(indexes, i)
+		headers = append(headers, name)
+	}
+	return indexes, headers
+}
+
+func csvValue(val reflect.Value) string {
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return ""
+		}
+		val = val.Elem()
+	}
+	if marshaler, ok := val.Interface().(encoding.TextMarshaler); ok == true {
+		text, err := marshaler.MarshalText()
+		if err == nil {
+			return string(text)
+		}
+	}
+	return fmt.Sprint(val.Interface())
+}
+
+// outputCSV writes a struct or a slice of structs as CSV, one row per struct. The rows are written as they are
+// encoded, the response is not buffered.
+func outputCSV(w http.ResponseWriter, r *http.Request, code int, data interface{}) error {
+	val := reflect.ValueOf(data)
+	for val.Kind() == reflect.Ptr {
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Slice && val.Kind() != reflect.Array {
+		val = reflect.Append(reflect.MakeSlice(reflect.SliceOf(val.Type()), 0, 1), val)
+	}
+	t := val.Type().Elem()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	indexes, headers := csvColumns(t)
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.WriteHeader(code)
+	s := &streamWriter{w: w, done: r.Context().Done()}
+	writer := csv.NewWriter(s)
+	err := writer.Write(headers)
+	if err != nil {
+		return err
+	}
+	row := make([]string, len(indexes))
+	for i := 0; i < val.Len(); i++ {
+		item := val.Index(i)
+		for item.Kind() == reflect.Ptr {
+			item = item.Elem()
+		}
+		if item.IsValid() == false {
+			continue
+		}
+		for j, index := range indexes {
+			row[j] = csvValue(item.Field(index))
+		}
+		err = writer.Write(row)
+		if err != nil {
+			return err
+		}
+		if (i+1)%csvFlushRows == 0 {
+			writer.Flush()
+			if writer.Error() != nil {
+				return writer.Error()
+			}
+			s.Flush()
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
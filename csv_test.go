@@ -0,0 +1,30 @@
+package rest
+
+import "testing"
+
+func TestCSVEncodable(t *testing.T) {
+	type row struct {
+		Name string
+	}
+	var nilRow *row
+	var nilRows *[]row
+	tests := []struct {
+		name string
+		data interface{}
+		ok   bool
+	}{
+		{"struct", row{}, true},
+		{"pointer", &row{}, true},
+		{"slice", []row{}, true},
+		{"slice of pointers", []*row{nil}, true},
+		{"nil", nil, false},
+		{"nil pointer", nilRow, false},
+		{"nil pointer to a slice", nilRows, false},
+		{"string", "a", false},
+	}
+	for _, test := range tests {
+		if ok := csvEncodable(test.data); ok != test.ok {
+			t.Errorf("%s: got %t", test.name, ok)
+		}
+	}
+}
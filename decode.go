@@ -0,0 +1,158 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/url"
+	"reflect"
+	"strconv"
+)
+
+// ParseLimits bounds the documents decoded by Parse and DecodeBody, a zero limit disables the check
+type ParseLimits struct {
+	// MaxSize is the maximum size of the document in bytes. Parse ignores it, the body being limited by the
+	// MaxBodySize of the route.
+	MaxSize int64
+	// MaxDepth is the maximum nesting of the JSON objects and arrays, and of the XML elements
+	MaxDepth int
+	// MaxFields is the maximum number of values of a form
+	MaxFields int
+}
+
+// DefaultParseLimits are the limits of Parse, and of DecodeBody when given zero limits
+var DefaultParseLimits = ParseLimits{
+	MaxSize:   10 << 20,
+	MaxDepth:  64,
+	MaxFields: 1000,
+}
+
+// parseLimits returns the limits of Parse: the default ones, but the size which is limited by the route
+func parseLimits() ParseLimits {
+	limits := DefaultParseLimits
+	limits.MaxSize = 0
+	return limits
+}
+
+// DecodeBody decodes data, a document of the media type ctype (JSON, XML, JSON:API, HAL or form), into v as Parse
+// does. Unlike Parse it does not depend on a request or on the route: the values are not coerced and the times are
+// RFC 3339 or Unix timestamps, and the XML documents are decoded with DefaultXMLOptions. It returns an APIError: 413
+// if data is too large, 415 if ctype is not supported and 400 if data is invalid or exceeds the limits.
+func DecodeBody(ctype string, data []byte, v interface{}, limits ParseLimits) error {
+	if limits == (ParseLimits{}) {
+		limits = DefaultParseLimits
+	}
+	if limits.MaxSize > 0 && int64(len(data)) > limits.MaxSize {
+		return NewAPIError(413, "request body too large")
+	}
+	mediaType, _, _ := mime.ParseMediaType(ctype)
+	format, ok := mediaFormats[mediaType]
+	if ok == false {
+		return NewAPIError(415, "unsupported Content-Type: "+ctype)
+	}
+	if format == formatFORM {
+		form, err := url.ParseQuery(string(data))
+		if err != nil {
+			return NewAPIError(400, "failed to parse body: "+err.Error())
+		}
+		return decodeForm(form, v, coercion{}, limits)
+	}
+	return decodeBody(format, data, v, coercion{}, limits, DefaultXMLOptions)
+}
+
+// mediaFormats are the formats of the media types of the bodies
+var mediaFormats = map[string]int{
+	"application/json":                  formatJSON,
+	"application/xml":                   formatXML,
+	"application/x-www-form-urlencoded": formatFORM,
+	"application/vnd.api+json":          formatJSONAPI,
+	"application/hal+json":              formatHAL,
+}
+
+// decodeBody decodes the JSON, XML (with opts), JSON:API or HAL document chunk into v, checks the enums and returns
+// the errors as API errors
+func decodeBody(format int, chunk []byte, v interface{}, c coercion, limits ParseLimits, opts XMLOptions) error {
+	var err error
+
+	switch format {
+	case formatJSON, formatHAL, formatJSONAPI:
+		err = checkJSONDepth(chunk, limits.MaxDepth)
+		if err != nil {
+			break
+		}
+		if format == formatJSONAPI {
+			err = unmarshalJSONAPI(chunk, v)
+			if _, ok := err.(Error); ok == true {
+				return err
+			}
+			break
+		}
+		if t := reflect.TypeOf(v); t != nil && (c.lenient == true || hasTimeFields(t) == true) {
+			var errs []FieldError
+			chunk, err = coerceJSON(chunk, t, c, &errs)
+			if len(errs) != 0 {
+				return ValidationError{Message: "invalid body", Errors: errs}
+			}
+		}
+		if err == nil {
+			err = json.Unmarshal(chunk, v)
+		}
+	case formatXML:
+		err = decodeXML(chunk, v, opts, limits)
+		if _, ok := err.(Error); ok == true {
+			return err
+		}
+	default:
+		return errors.New("unknown output format")
+	}
+	if err != nil {
+		return NewAPIError(400, "failed to parse body: "+err.Error())
+	}
+	return checkEnums(v)
+}
+
+// decodeForm decodes the form into v, checks the enums and returns the errors as API errors
+func decodeForm(form url.Values, v interface{}, c coercion, limits ParseLimits) error {
+	if limits.MaxFields > 0 {
+		fields := 0
+		for _, values := range form {
+			fields += len(values)
+		}
+		if fields > limits.MaxFields {
+			return NewAPIError(400, "failed to parse body: more than "+strconv.Itoa(limits.MaxFields)+" fields")
+		}
+	}
+	err := parseForm(form, v, c)
+	if _, ok := err.(ValidationError); ok == true {
+		return err
+	} else if err != nil {
+		return NewAPIError(400, "failed to parse body: "+err.Error())
+	}
+	return checkEnums(v)
+}
+
+// checkJSONDepth returns an error if the objects and arrays of the JSON document chunk are nested deeper than max
+func checkJSONDepth(chunk []byte, max int) error {
+	if max <= 0 {
+		return nil
+	}
+	depth := 0
+	inString := false
+	for i := 0; i < len(chunk); i++ {
+		switch c := chunk[i]; {
+		case inString == true && c == '\\':
+			i++
+		case c == '"':
+			inString = !inString
+		case inString == true:
+		case c == '{' || c == '[':
+			depth++
+			if depth > max {
+				return errors.New("the document is nested deeper than " + strconv.Itoa(max) + " levels")
+			}
+		case c == '}' || c == ']':
+			depth--
+		}
+	}
+	return nil
+}
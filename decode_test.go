@@ -0,0 +1,169 @@
+package rest
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fuzzInner is embedded in fuzzBody
+type fuzzInner struct {
+	Note string `json:"note" xml:"note"`
+}
+
+// fuzzBody has a field of each kind handled by the decoders
+type fuzzBody struct {
+	fuzzInner
+	Name     string            `json:"name" xml:"name,attr"`
+	Count    int               `json:"count" xml:"count"`
+	Ratio    float64           `json:"ratio" xml:"ratio"`
+	Enabled  bool              `json:"enabled" xml:"enabled"`
+	Sort     string            `json:"sort" xml:"sort" enum:"name,date,size"`
+	At       time.Time         `json:"at" xml:"at"`
+	Timeout  time.Duration     `json:"timeout" xml:"timeout"`
+	Tags     []string          `json:"tags" xml:"tags>tag"`
+	Labels   map[string]string `json:"labels" xml:"-"`
+	Children []fuzzBody        `json:"children" xml:"children>child"`
+	Parent   *fuzzBody         `json:"parent" xml:"parent"`
+	secret   string
+}
+
+// fuzzDecode decodes data as ctype into a fuzzBody twice, the errors must be API errors and deterministic
+func fuzzDecode(t *testing.T, ctype string, data []byte) {
+	var first, second fuzzBody
+
+	err := DecodeBody(ctype, data, &first, DefaultParseLimits)
+	if err == nil {
+		return
+	}
+	if _, ok := err.(Error); ok == false {
+		t.Fatalf("%T is not an API error: %s", err, err)
+	}
+	if again := DecodeBody(ctype, data, &second, DefaultParseLimits); fmt.Sprint(again) != fmt.Sprint(err) {
+		t.Fatalf("non-deterministic errors: %s, then %s", err, again)
+	}
+}
+
+func FuzzJSON(f *testing.F) {
+	for _, seed := range []string{
+		`{"name": "a", "count": 1, "ratio": 0.5, "enabled": true, "sort": "date", "tags": ["x", "y"]}`,
+		`{"at": "2006-01-02T15:04:05Z", "timeout": "1m30s", "labels": {"a": "b"}, "note": "n"}`,
+		`{"at": 1136214245000, "timeout": 90, "parent": {"children": [{"name": "c"}]}}`,
+		`{"sort": "other"}`,
+		`{"name": "é\"\\", "count": 1e3}`,
+		strings.Repeat("[", 100) + strings.Repeat("]", 100),
+	} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		fuzzDecode(t, "application/json", data)
+	})
+}
+
+func FuzzXML(f *testing.F) {
+	for _, seed := range []string{
+		`<fuzzBody name="a"><count>1</count><ratio>0.5</ratio><enabled>true</enabled><tags><tag>x</tag></tags></fuzzBody>`,
+		`<fuzzBody><at>2006-01-02T15:04:05Z</at><parent><children><child name="c"/></children></parent></fuzzBody>`,
+		`<?xml version="1.0"?><!DOCTYPE a [<!ENTITY e "x">]><fuzzBody><note>&e;</note></fuzzBody>`,
+		`<a xmlns:p="urn:p"><p:b/></a>`,
+		strings.Repeat("<a>", 100) + strings.Repeat("</a>", 100),
+		`<!DOCTYPE a [<!ENTITY a "aaaaaaaaaa"><!ENTITY b "&a;&a;&a;&a;&a;">]><fuzzBody><note>&b;&b;</note></fuzzBody>`,
+		`<!DOCTYPE a SYSTEM "file:///etc/passwd"><fuzzBody><note>&xxe;</note></fuzzBody>`,
+	} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var v fuzzBody
+
+		fuzzDecode(t, "application/xml", data)
+		// with the DTDs allowed, the decoding must not panic either
+		opts := DefaultXMLOptions
+		opts.AllowDTD = true
+		decodeXML(data, &v, opts, DefaultParseLimits)
+	})
+}
+
+func FuzzForm(f *testing.F) {
+	for _, seed := range []string{
+		"name=a&count=1&ratio=0.5&enabled=true&sort=date",
+		"at=2006-01-02T15:04:05Z&timeout=1m30s&note=n",
+		"NAME=a&name=b&secret=s&tags=x",
+		"count=x&at=never&timeout=-&sort=other",
+		"%zz=1&a=%",
+	} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var v fuzzBody
+
+		fuzzDecode(t, "application/x-www-form-urlencoded", data)
+		// the coercion of the lenient routes
+		form, err := url.ParseQuery(string(data))
+		if err == nil {
+			err = decodeForm(form, &v, coercion{lenient: true}, DefaultParseLimits)
+			if _, ok := err.(Error); err != nil && ok == false {
+				t.Fatalf("%T is not an API error: %s", err, err)
+			}
+		}
+	})
+}
+
+func TestDecodeBodyLimits(t *testing.T) {
+	limits := ParseLimits{MaxSize: 100, MaxDepth: 3, MaxFields: 2}
+	tests := []struct {
+		name  string
+		ctype string
+		body  string
+		code  int
+	}{
+		{"json", "application/json", `{"name": "a", "parent": {"name": "b"}}`, 0},
+		{"json too deep", "application/json", `{"parent": {"parent": {"parent": {}}}}`, 400},
+		{"json brackets in strings", "application/json", `{"name": "[[[[{{{{"}`, 0},
+		{"json too large", "application/json", `{"name": "` + strings.Repeat("a", 100) + `"}`, 413},
+		{"xml too deep", "application/xml", `<fuzzBody><parent><parent><parent/></parent></parent></fuzzBody>`, 400},
+		{"form", "application/x-www-form-urlencoded", "name=a&count=1", 0},
+		{"form too many fields", "application/x-www-form-urlencoded", "name=a&count=1&note=b", 400},
+		{"form invalid", "application/x-www-form-urlencoded", "count=x", 0},
+		{"unsupported", "text/plain", "a", 415},
+		{"enum", "application/json", `{"sort": "other"}`, 400},
+	}
+	for _, test := range tests {
+		var v fuzzBody
+		err := DecodeBody(test.ctype, []byte(test.body), &v, limits)
+		code := 0
+		if err != nil {
+			code = err.(Error).StatusCode()
+		}
+		if code != test.code {
+			t.Errorf("%s: got %d (%v), expected %d", test.name, code, err, test.code)
+		}
+	}
+}
+
+func TestDecodeFormTargets(t *testing.T) {
+	type Inner struct {
+		Note string
+	}
+	type embedded struct {
+		*Inner
+		Name string
+	}
+	tests := []struct {
+		name string
+		v    interface{}
+		ok   bool
+	}{
+		{"not a pointer", fuzzBody{}, false},
+		{"not a struct", new(int), false},
+		{"nil embedded pointer", new(embedded), true},
+		{"unexported field", new(fuzzBody), true},
+	}
+	for _, test := range tests {
+		err := DecodeBody("application/x-www-form-urlencoded", []byte("note=a&name=b&secret=c"), test.v, ParseLimits{})
+		if (err == nil) != test.ok {
+			t.Errorf("%s: got %v", test.name, err)
+		}
+	}
+}
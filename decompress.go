@@ -0,0 +1,92 @@
+package rest
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// MaxDecompressedSize is the maximum size, in bytes, of a compressed request body once decompressed.
+// Bigger bodies are rejected with a 413 in order to prevent decompression bombs.
+var MaxDecompressedSize int64 = 10 << 20
+
+var errBodyTooLarge = errors.New("body too large")
+
+// limitReader behaves like io.LimitReader but fails instead of silently truncating the body.
+type limitReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		// make sure the body really is bigger than the limit before failing
+		var b [1]byte
+		if n, _ := l.r.Read(b[:]); n > 0 {
+			return 0, errBodyTooLarge
+		}
+		return 0, io.EOF
+	}
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	return n, err
+}
+
+type decompressedBody struct {
+	io.Reader
+	decoder io.Closer
+	body    io.Closer
+}
+
+func (b decompressedBody) Close() error {
+	b.decoder.Close()
+	return b.body.Close()
+}
+
+// decompressBody replaces the body of the request by its decompressed version according to the Content-Encoding header.
+// gzip and deflate are supported.
+func decompressBody(r *http.Request) error {
+	var decoder io.ReadCloser
+	var err error
+
+	encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding")))
+	if encoding == "" || encoding == "identity" {
+		return nil
+	} else if encoding == "gzip" || encoding == "x-gzip" {
+		decoder, err = gzip.NewReader(r.Body)
+	} else if encoding == "deflate" {
+		decoder, err = zlib.NewReader(r.Body)
+	} else {
+		return NewAPIError(415, "unsupported Content-Encoding: "+encoding)
+	}
+	if err != nil {
+		return NewAPIError(400, "failed to decompress body: "+err.Error())
+	}
+	r.Body = decompressedBody{
+		Reader:  &limitReader{decoder, MaxDecompressedSize},
+		decoder: decoder,
+		body:    r.Body,
+	}
+	r.Header.Del("Content-Encoding")
+	r.Header.Del("Content-Length")
+	r.ContentLength = -1
+	return nil
+}
+
+// readBody reads the whole (decompressed) body of the request
+func readBody(r *http.Request) ([]byte, error) {
+	chunk, err := ioutil.ReadAll(r.Body)
+	if err == errBodyTooLarge {
+		return nil, NewAPIError(413, "request body too large")
+	} else if err != nil {
+		return nil, Error500{"failed to read body"}
+	}
+	return chunk, nil
+}
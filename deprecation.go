@@ -0,0 +1,50 @@
+package rest
+
+import (
+	"log"
+	"net/http"
+	"time"
+)
+
+// Deprecation describes the deprecation of a route
+type Deprecation struct {
+	// Sunset is the date the route will stop being served, zero if unknown
+	Sunset time.Time
+	// Link is the URL of the documentation of the deprecation (migration guide...), may be empty
+	Link string
+	// LogUsage logs every call to the route
+	LogUsage bool
+}
+
+// Deprecated marks the route as deprecated: its responses get the Deprecation, Sunset and Link headers, and every
+// call is counted in the metrics (rest.routes.deprecated).
+func (rt *Route) Deprecated(sunset time.Time, link string) *Route {
+	return rt.WithDeprecation(Deprecation{Sunset: sunset, Link: link})
+}
+
+// WithDeprecation marks the route as deprecated, see Deprecated
+func (rt *Route) WithDeprecation(deprecation Deprecation) *Route {
+	return rt.update(func(options *RouteOptions) {
+		options.Deprecation = &deprecation
+	})
+}
+
+// deprecate sets the deprecation headers and records the usage of the route
+func (d *Deprecation) deprecate(r *http.Request, state *requestState) {
+	if state.w != nil {
+		header := state.w.Header()
+		header.Set("Deprecation", "true")
+		if d.Sunset.IsZero() == false {
+			header.Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+		}
+		if d.Link != "" {
+			header.Add("Link", "<"+d.Link+`>; rel="deprecation"`)
+		}
+	}
+	if d.LogUsage == true {
+		log.Printf("deprecated route called: %s %s from %s\n", r.Method, r.URL.Path, ClientIP(r))
+	}
+	if router := routerFromRequest(r); router != nil {
+		router.incr("rest.routes.deprecated", state.tags())
+	}
+}
@@ -0,0 +1,94 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// SetDev enables or disables the development mode: the server errors are detailed in the responses (cause chain and
+// stack trace of the errors recording one), the JSON responses are indented, the caching headers are replaced by
+// "Cache-Control: no-store" and the templates are reloaded when their files change. It must not be enabled in
+// production.
+func (r *Router) SetDev(dev bool) {
+	r.mu.Lock()
+	r.config.Dev = dev
+	r.mu.Unlock()
+}
+
+// isDev returns whether the router is in development mode, false if r is nil
+func (r *Router) isDev() bool {
+	if r == nil {
+		return false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.config.Dev
+}
+
+// devError is the response of the server errors in development mode
+type devError struct {
+	Message string
+	Status  int      `json:"-" xml:"-"`
+	Causes  []string `json:",omitempty"`
+	// Stack is the stack trace recorded by the error when it was created, see stackOf
+	Stack string `json:",omitempty"`
+	// Header is the header of the request, the secrets being masked by the redactor of the router
+	Header http.Header `json:",omitempty" xml:"-"`
+}
+
+// StatusCode returns the status code of the error
+func (e devError) StatusCode() int {
+	return e.Status
+}
+
+// newDevError details err, returned with the given status code
+func newDevError(r *http.Request, err error, code int) devError {
+	rd := routerFromRequest(r).getRedactor()
+	if rd == nil {
+		rd = DefaultRedactor()
+	}
+	e := devError{
+		Message: rd.Body(err.Error()),
+		Status:  code,
+		Stack:   rd.Body(stackOf(err)),
+		Header:  rd.Header(r.Header),
+	}
+	for cause := causeOf(err); cause != nil; cause = causeOf(cause) {
+		e.Causes = append(e.Causes, rd.Body(fmt.Sprintf("%T: %s", cause, cause)))
+	}
+	return e
+}
+
+// stackOf returns the stack trace recorded by err or one of its causes, empty if none did. The errors recording their
+// stack trace (github.com/pkg/errors...) print it with the %+v verb.
+func stackOf(err error) string {
+	for ; err != nil; err = causeOf(err) {
+		if _, ok := err.(fmt.Formatter); ok == false {
+			continue
+		}
+		if detailed := fmt.Sprintf("%+v", err); detailed != err.Error() {
+			return detailed
+		}
+	}
+	return ""
+}
+
+// causeOf returns the error wrapped by err, nil if it does not wrap any
+func causeOf(err error) error {
+	if cause := errors.Unwrap(err); cause != nil {
+		return cause
+	}
+	if transparent, ok := err.(ErrorTransparent); ok == true {
+		return transparent.Parent()
+	}
+	return nil
+}
+
+// noCache replaces the caching headers of the response
+func noCache(h http.Header) {
+	h.Set("Cache-Control", "no-store")
+	h.Del("Expires")
+	h.Del("ETag")
+	h.Del("Last-Modified")
+}
@@ -0,0 +1,42 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// stackError records a fake stack trace, printed with %+v like github.com/pkg/errors
+type stackError struct {
+	msg string
+}
+
+func (e stackError) Error() string {
+	return e.msg
+}
+
+func (e stackError) Format(s fmt.State, verb rune) {
+	if verb == 'v' && s.Flag('+') == true {
+		fmt.Fprint(s, e.msg+"\nmain.handler\n\tmain.go:12")
+		return
+	}
+	fmt.Fprint(s, e.msg)
+}
+
+func TestStackOf(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		stack string
+	}{
+		{"plain", errors.New("failed"), ""},
+		{"recorded", stackError{"failed"}, "failed\nmain.handler\n\tmain.go:12"},
+		{"wrapped", fmt.Errorf("query: %w", stackError{"failed"}), "failed\nmain.handler\n\tmain.go:12"},
+		{"transparent", errorTransparent{NewError500(), stackError{"failed"}}, "failed\nmain.handler\n\tmain.go:12"},
+	}
+	for _, test := range tests {
+		if stack := stackOf(test.err); stack != test.stack {
+			t.Errorf("%s: got %q", test.name, stack)
+		}
+	}
+}
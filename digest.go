@@ -0,0 +1,81 @@
+package rest
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"hash"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// digestAlgorithms are the RFC 3230 algorithms supported to verify the requests
+var digestAlgorithms = map[string]func() hash.Hash{
+	"md5":     md5.New,
+	"sha":     sha1.New,
+	"sha-256": sha256.New,
+	"sha-512": sha512.New,
+}
+
+// Digest is a Middleware verifying the integrity of the request bodies and adding digests to the responses.
+// The request body is checked against its Content-MD5 and Digest (RFC 3230) headers when present, a mismatch is
+// rejected with a 400. The encoded responses get Content-MD5 and Digest (SHA-256) headers, streamed responses don't.
+func Digest(next Controller) Controller {
+	return func(r *http.Request, p Params) (interface{}, error) {
+		err := verifyDigest(r)
+		if err != nil {
+			return nil, err
+		}
+		stateFromRequest(r).digest = true
+		return next(r, p)
+	}
+}
+
+// verifyDigest reads the body to verify it, when the request has digest headers, and replaces it by its content. A
+// body over the MaxBodySize of the route is rejected with a 413.
+func verifyDigest(r *http.Request) error {
+	expected := make(map[string]string)
+	if contentMD5 := r.Header.Get("Content-MD5"); contentMD5 != "" {
+		expected["md5"] = contentMD5
+	}
+	for _, header := range r.Header["Digest"] {
+		for _, digest := range strings.Split(header, ",") {
+			kv := strings.SplitN(strings.TrimSpace(digest), "=", 2)
+			if len(kv) == 2 {
+				if _, ok := digestAlgorithms[strings.ToLower(kv[0])]; ok == true {
+					expected[strings.ToLower(kv[0])] = kv[1]
+				}
+			}
+		}
+	}
+	if len(expected) == 0 || r.Body == nil {
+		return nil
+	}
+
+	chunk, err := readBody(r)
+	r.Body.Close()
+	if err != nil {
+		return err
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(chunk))
+	for algorithm, digest := range expected {
+		h := digestAlgorithms[algorithm]()
+		h.Write(chunk)
+		if base64.StdEncoding.EncodeToString(h.Sum(nil)) != digest {
+			return NewAPIError(400, "body does not match its "+algorithm+" digest")
+		}
+	}
+	return nil
+}
+
+// setDigest adds the Content-MD5 and Digest headers of the response body
+func setDigest(header http.Header, data []byte) {
+	sumMD5 := md5.Sum(data)
+	sumSHA256 := sha256.Sum256(data)
+	header.Set("Content-MD5", base64.StdEncoding.EncodeToString(sumMD5[:]))
+	header.Set("Digest", "SHA-256="+base64.StdEncoding.EncodeToString(sumSHA256[:]))
+}
@@ -0,0 +1,32 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+)
+
+// errClientGone is returned by the stream writers once the client disconnected
+var errClientGone = errors.New("client disconnected")
+
+// Done returns a channel closed when the client goes away (or the request times out), so that the long running and
+// streaming controllers can stop early:
+//
+//	select {
+//	case <-rest.Done(r):
+//		return nil, nil
+//	case row := <-rows:
+//		...
+//	}
+func Done(r *http.Request) <-chan struct{} {
+	return r.Context().Done()
+}
+
+// ClientGone tells whether the client of the request went away
+func ClientGone(r *http.Request) bool {
+	select {
+	case <-r.Context().Done():
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,30 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Preload returns the Link header value asking the client to preload url, as is "style", "script", "font"...
+func Preload(url, as string) string {
+	return "<" + url + ">; rel=preload; as=" + as
+}
+
+// EarlyHints sends a 103 Early Hints informational response with the given Link header values, so that the client
+// can start loading the resources of a page (see Preload) while the controller is still building it. It may be called
+// once, before the controller returns.
+func EarlyHints(r *http.Request, links ...string) error {
+	state := stateFromRequest(r)
+	if state.w == nil {
+		return errors.New("early hints can only be sent by a controller")
+	}
+	if state.hinted == true {
+		return errors.New("early hints already sent")
+	}
+	state.hinted = true
+	for _, link := range links {
+		state.w.Header().Add("Link", link)
+	}
+	state.w.WriteHeader(103)
+	return nil
+}
@@ -0,0 +1,143 @@
+package rest
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// enumFields caches whether the types have fields with an enum tag
+var enumFields sync.Map
+
+// checkEnums checks the fields of v with an enum tag, listing their allowed values:
+//
+//	type Search struct {
+//		Sort string `json:"sort" enum:"name,date,size"`
+//	}
+//
+// The zero values are not checked, the fields may be strings, numbers or slices of them.
+func checkEnums(v interface{}) error {
+	var errs []FieldError
+
+	val := reflect.ValueOf(v)
+	if val.IsValid() == false || hasEnumFields(val.Type()) == false {
+		return nil
+	}
+	walkEnums(val, "", &errs)
+	if len(errs) != 0 {
+		return ValidationError{Message: "invalid values", Errors: errs}
+	}
+	return nil
+}
+
+func walkEnums(val reflect.Value, path string, errs *[]FieldError) {
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() == true {
+			return
+		}
+		val = val.Elem()
+	}
+	switch val.Kind() {
+	case reflect.Struct:
+		t := val.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			name := joinPath(path, fieldName(field))
+			if field.Anonymous == true {
+				name = path
+			}
+			if tag, ok := field.Tag.Lookup("enum"); ok == true {
+				checkEnum(val.Field(i), strings.Split(tag, ","), name, errs)
+			} else if hasEnumFields(field.Type) == true {
+				walkEnums(val.Field(i), name, errs)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < val.Len(); i++ {
+			walkEnums(val.Index(i), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	case reflect.Map:
+		for _, key := range val.MapKeys() {
+			walkEnums(val.MapIndex(key), joinPath(path, fmt.Sprint(key.Interface())), errs)
+		}
+	}
+}
+
+// checkEnum checks a field with an enum tag
+func checkEnum(val reflect.Value, allowed []string, path string, errs *[]FieldError) {
+	for val.Kind() == reflect.Ptr {
+		if val.IsNil() == true {
+			return
+		}
+		val = val.Elem()
+	}
+	if val.Kind() == reflect.Slice || val.Kind() == reflect.Array {
+		for i := 0; i < val.Len(); i++ {
+			checkEnum(val.Index(i), allowed, fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+		return
+	}
+	if val.IsZero() == true {
+		return
+	}
+	value := fmt.Sprint(val.Interface())
+	for _, a := range allowed {
+		if strings.TrimSpace(a) == value {
+			return
+		}
+	}
+	*errs = append(*errs, FieldError{
+		Field:   path,
+		Message: fmt.Sprintf("%q is not allowed, must be one of: %s", value, strings.Join(allowed, ", ")),
+	})
+}
+
+// fieldName returns the name of the field in the JSON documents
+func fieldName(field reflect.StructField) string {
+	tag := field.Tag.Get("json")
+	if comma := strings.Index(tag, ","); comma != -1 {
+		tag = tag[:comma]
+	}
+	if tag != "" && tag != "-" {
+		return tag
+	}
+	return field.Name
+}
+
+// hasEnumFields returns whether the values of t contain fields with an enum tag
+func hasEnumFields(t reflect.Type) bool {
+	if found, ok := enumFields.Load(t); ok == true {
+		return found.(bool)
+	}
+	found := searchEnumFields(t, make(map[reflect.Type]bool))
+	enumFields.Store(t, found)
+	return found
+}
+
+func searchEnumFields(t reflect.Type, seen map[reflect.Type]bool) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if seen[t] == true {
+		return false
+	}
+	seen[t] = true
+	switch t.Kind() {
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if _, ok := t.Field(i).Tag.Lookup("enum"); ok == true {
+				return true
+			}
+			if searchEnumFields(t.Field(i).Type, seen) == true {
+				return true
+			}
+		}
+	case reflect.Map, reflect.Slice, reflect.Array:
+		return searchEnumFields(t.Elem(), seen)
+	}
+	return false
+}
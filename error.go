@@ -1,5 +1,11 @@
 package rest
 
+import (
+	"errors"
+	"log"
+	"net/http"
+)
+
 // Error is the interface that needs to be implemented in order to return meaningfull errors to the client.
 type Error interface {
 	StatusCode() int
@@ -30,3 +36,130 @@ func (e Error500) Error() string {
 func (e Error500) StatusCode() int {
 	return 500
 }
+
+// APIError is an easy way to return errors with any status code
+type APIError struct {
+	Code    int `json:"-" xml:"-"`
+	Message string
+}
+
+// NewAPIError creates an APIError with the given status code and message
+func NewAPIError(code int, message string) APIError {
+	return APIError{
+		Code:    code,
+		Message: message,
+	}
+}
+
+func (e APIError) Error() string {
+	return e.Message
+}
+
+// StatusCode returns the status code of the error
+func (e APIError) StatusCode() int {
+	return e.Code
+}
+
+// errorTransparent hides its parent error to the client while keeping it in the logs
+type errorTransparent struct {
+	Error500
+	parent error
+}
+
+// Parent returns the underlying error
+func (e errorTransparent) Parent() error {
+	return e.parent
+}
+
+// Unwrap returns the underlying error, for errors.Is and errors.As
+func (e errorTransparent) Unwrap() error {
+	return e.parent
+}
+
+// wrappedError returns its status code and message to the client and keeps its cause for errors.Is, errors.As and
+// the logs
+type wrappedError struct {
+	APIError
+	cause error
+}
+
+// Parent returns the cause of the error
+func (e wrappedError) Parent() error {
+	return e.cause
+}
+
+// Unwrap returns the cause of the error
+func (e wrappedError) Unwrap() error {
+	return e.cause
+}
+
+// Wrap returns an error sent to the client with the given status code and message, err is kept as its cause:
+//
+//	if err == sql.ErrNoRows {
+//		return nil, rest.Wrap(err, 404, "no such user")
+//	}
+func Wrap(err error, code int, message string) error {
+	if err == nil {
+		return nil
+	}
+	return wrappedError{NewAPIError(code, message), err}
+}
+
+// errorMapping maps the errors matching target (errors.Is) to a status code and a message
+type errorMapping struct {
+	target  error
+	code    int
+	message string
+}
+
+// MapError makes the controllers returning an error matching target (errors.Is), possibly wrapped, reply with the
+// given status code and message instead of a 500. The errors implementing Error anywhere in the chain keep their own
+// status code.
+//
+//	router.MapError(sql.ErrNoRows, 404, "not found")
+func (r *Router) MapError(target error, code int, message string) {
+	r.mu.Lock()
+	r.errorMappings = append(r.errorMappings, errorMapping{target, code, message})
+	r.mu.Unlock()
+}
+
+// mapError returns the error sent to the client for err
+func (r *Router) mapError(err error) Error {
+	var e Error
+	if errors.As(err, &e) == true {
+		return e
+	}
+	if r != nil {
+		r.mu.RLock()
+		mappings := r.errorMappings
+		r.mu.RUnlock()
+		for _, mapping := range mappings {
+			if errors.Is(err, mapping.target) == true {
+				return NewAPIError(mapping.code, mapping.message)
+			}
+		}
+	}
+	return NewError500()
+}
+
+// writeError logs the error returned by a controller and sends it to the client
+func writeError(w http.ResponseWriter, r *http.Request, err error, format int) {
+	router := routerFromRequest(r)
+	e := router.mapError(err)
+	var parent error
+	var transparent ErrorTransparent
+	if errors.As(err, &transparent) == true {
+		parent = transparent.Parent()
+	}
+	router.logError(r, err, e.StatusCode(), parent)
+	if e.StatusCode() >= 500 {
+		router.report(r, ErrorReport{Err: err, Status: e.StatusCode()})
+		if router.isDev() == true {
+			e = newDevError(r, err, e.StatusCode())
+		}
+	}
+	err = output(w, r, e.StatusCode(), e, format)
+	if err != nil {
+		log.Println("error while writing error:", err)
+	}
+}
@@ -0,0 +1,73 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+)
+
+// Event is a domain event emitted by a controller (user.created, order.paid...)
+type Event struct {
+	Name    string
+	Payload interface{}
+}
+
+// EventBus publishes the events (message queue, webhooks...)
+type EventBus interface {
+	Publish(ctx context.Context, events []Event) error
+}
+
+// EventBusFunc adapts a function to an EventBus
+type EventBusFunc func(ctx context.Context, events []Event) error
+
+// Publish calls f
+func (f EventBusFunc) Publish(ctx context.Context, events []Event) error {
+	return f(ctx, events)
+}
+
+// SetEventBus sets the bus the events emitted by the controllers are published to
+func (r *Router) SetEventBus(bus EventBus) {
+	r.mu.Lock()
+	r.eventBus = bus
+	r.mu.Unlock()
+}
+
+// Emit attaches an event to the response of the request. The events are published to the bus of the router once the
+// response is successfully sent, they are dropped if the controller fails or the response can't be written, so that
+// the side effects are consistent with what the client saw.
+func Emit(r *http.Request, name string, payload interface{}) {
+	state := stateFromRequest(r)
+	state.eventsMu.Lock()
+	state.events = append(state.events, Event{name, payload})
+	state.eventsMu.Unlock()
+}
+
+// publishEvents publishes the events of the request, once its response is written
+func publishEvents(w http.ResponseWriter, r *http.Request, state *requestState) {
+	state.eventsMu.Lock()
+	events := state.events
+	state.events = nil
+	state.eventsMu.Unlock()
+	if len(events) == 0 {
+		return
+	}
+
+	var bus EventBus
+	router := routerFromRequest(r)
+	if router != nil {
+		router.mu.RLock()
+		bus = router.eventBus
+		router.mu.RUnlock()
+	}
+	if bus == nil {
+		Log(r).Logf(LevelWarn, "%d events dropped, no event bus set\n", len(events))
+		return
+	}
+	// the client gets its response before the events are published
+	if flusher, ok := w.(http.Flusher); ok == true {
+		flusher.Flush()
+	}
+	if err := bus.Publish(r.Context(), events); err != nil {
+		Log(r).Logf(LevelError, "failed to publish %d events: %s\n", len(events), err)
+		router.incr("rest.events.failed", state.tags())
+	}
+}
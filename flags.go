@@ -0,0 +1,93 @@
+package rest
+
+import (
+	"net/http"
+	"sync"
+)
+
+// FlagProvider tells whether the feature flags are enabled, adapt the flag service of your choice to it. The request
+// allows partial rollouts (per user, per tenant, by percentage...).
+type FlagProvider interface {
+	Enabled(r *http.Request, flag string) bool
+}
+
+// FlagSetter is implemented by the providers whose flags can be changed, through the admin API for instance
+type FlagSetter interface {
+	SetFlag(flag string, enabled bool)
+	Flags() map[string]bool
+}
+
+// StaticFlags is a FlagProvider in memory, the unknown flags are disabled
+type StaticFlags struct {
+	mu    sync.RWMutex
+	flags map[string]bool
+}
+
+// NewStaticFlags creates a StaticFlags with the given flags
+func NewStaticFlags(flags map[string]bool) *StaticFlags {
+	f := &StaticFlags{flags: make(map[string]bool)}
+	for name, enabled := range flags {
+		f.flags[name] = enabled
+	}
+	return f
+}
+
+// Enabled returns whether flag is enabled, for all the requests
+func (f *StaticFlags) Enabled(r *http.Request, flag string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.flags[flag]
+}
+
+// SetFlag enables or disables flag, it can be called while serving
+func (f *StaticFlags) SetFlag(flag string, enabled bool) {
+	f.mu.Lock()
+	f.flags[flag] = enabled
+	f.mu.Unlock()
+}
+
+// Flags returns a copy of the flags
+func (f *StaticFlags) Flags() map[string]bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	flags := make(map[string]bool, len(f.flags))
+	for name, enabled := range f.flags {
+		flags[name] = enabled
+	}
+	return flags
+}
+
+// SetFlagProvider sets the provider of the feature flags of the router
+func (r *Router) SetFlagProvider(p FlagProvider) {
+	r.mu.Lock()
+	r.flags = p
+	r.mu.Unlock()
+}
+
+// flagProvider returns the flag provider of the router, nil if it has none or if r is nil
+func (r *Router) flagProvider() FlagProvider {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.flags
+}
+
+// FlagEnabled returns whether flag is enabled for the request, false if the router has no flag provider. The
+// controllers use it for partial rollouts:
+//
+//	if rest.FlagEnabled(r, "new-search") == true {
+//		return newSearch(r, p)
+//	}
+func FlagEnabled(r *http.Request, flag string) bool {
+	p := routerFromRequest(r).flagProvider()
+	return p != nil && p.Enabled(r, flag) == true
+}
+
+// Flag serves the route only if flag is enabled, the requests get a 404 otherwise (see RouteOptions.FlagStatus)
+func (rt *Route) Flag(flag string) *Route {
+	return rt.update(func(options *RouteOptions) {
+		options.Flag = flag
+	})
+}
@@ -0,0 +1,103 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// GraphQLRequest is a GraphQL query, as sent by the clients
+type GraphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// GraphQLError is an error of a GraphQL response
+type GraphQLError struct {
+	Message    string                 `json:"message"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// GraphQLResponse is the result of a GraphQL query
+type GraphQLResponse struct {
+	Data   interface{}    `json:"data,omitempty"`
+	Errors []GraphQLError `json:"errors,omitempty"`
+}
+
+// GraphQLSchema executes GraphQL queries, adapt the GraphQL library of your choice to it.
+// The context is the one of the HTTP request, carrying the values set by the middlewares.
+type GraphQLSchema interface {
+	Execute(ctx context.Context, req GraphQLRequest) *GraphQLResponse
+}
+
+// NewGraphQLError converts an error returned by a resolver to a GraphQLError, the same way the handler does for the
+// controllers: the status and message of an Error (possibly wrapped) or of an error mapped by Router.MapError are
+// kept, the other errors are logged and replaced by a 500. ctx is the one given to GraphQLSchema.Execute.
+func NewGraphQLError(ctx context.Context, err error, path ...interface{}) GraphQLError {
+	router, _ := ctx.Value(routerKey).(*Router)
+	e := router.mapError(err)
+	message := NewError500().Message
+	if e.StatusCode() >= 500 {
+		router.getLogger().Logf(LevelError, "graphql error: %s\n", err)
+	} else if err2, ok := e.(error); ok == true {
+		message = err2.Error()
+	}
+	return GraphQLError{
+		Message:    message,
+		Path:       path,
+		Extensions: map[string]interface{}{"status": e.StatusCode()},
+	}
+}
+
+// GraphQL mounts a GraphQL endpoint on path, queries are accepted through GET (query string) and POST
+// (application/json or application/graphql body)
+func (r *Router) GraphQL(path string, schema GraphQLSchema) {
+	r.GET(path, graphQLController(schema))
+	r.POST(path, graphQLController(schema))
+}
+
+// GraphQL mounts a GraphQL endpoint on path, relative to the prefix of the group, behind the middlewares of the group
+func (g *Group) GraphQL(path string, schema GraphQLSchema) {
+	g.GET(path, graphQLController(schema))
+	g.POST(path, graphQLController(schema))
+}
+
+func graphQLController(schema GraphQLSchema) Controller {
+	return func(r *http.Request, p Params) (interface{}, error) {
+		var req GraphQLRequest
+
+		if r.Method == "GET" {
+			query := r.URL.Query()
+			req.Query = query.Get("query")
+			req.OperationName = query.Get("operationName")
+			if variables := query.Get("variables"); variables != "" {
+				err := json.Unmarshal([]byte(variables), &req.Variables)
+				if err != nil {
+					return nil, NewAPIError(400, "invalid variables: "+err.Error())
+				}
+			}
+		} else if strings.HasPrefix(r.Header.Get("Content-Type"), "application/graphql") {
+			err := decompressBody(r)
+			if err != nil {
+				return nil, err
+			}
+			chunk, err := readBody(r)
+			if err != nil {
+				return nil, err
+			}
+			req.Query = string(chunk)
+		} else {
+			err := Parse(r, &req)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if req.Query == "" {
+			return nil, NewAPIError(400, "missing query")
+		}
+		return schema.Execute(r.Context(), req), nil
+	}
+}
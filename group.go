@@ -0,0 +1,76 @@
+package rest
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Middleware wraps a Controller in order to run code before and/or after it.
+type Middleware func(Controller) Controller
+
+// Group is a set of routes sharing a path prefix and middlewares.
+type Group struct {
+	router      *Router
+	prefix      string
+	middlewares []Middleware
+	headers     http.Header
+}
+
+// Group creates a new group of routes. The middlewares are run in the given order, before the controllers.
+func (r *Router) Group(prefix string, middlewares ...Middleware) *Group {
+	return &Group{
+		router:      r,
+		prefix:      strings.TrimSuffix(prefix, "/"),
+		middlewares: middlewares,
+	}
+}
+
+// Group creates a sub-group, inheriting the prefix and the middlewares of g
+func (g *Group) Group(prefix string, middlewares ...Middleware) *Group {
+	return &Group{
+		router:      g.router,
+		prefix:      g.prefix + strings.TrimSuffix(prefix, "/"),
+		middlewares: append(append([]Middleware{}, g.middlewares...), middlewares...),
+		headers:     g.headers.Clone(),
+	}
+}
+
+// Use adds middlewares to the group. They only apply to the routes registered afterwards.
+func (g *Group) Use(middlewares ...Middleware) {
+	g.middlewares = append(g.middlewares, middlewares...)
+}
+
+func (g *Group) wrap(ctrl Controller) Controller {
+	for i := len(g.middlewares) - 1; i >= 0; i-- {
+		ctrl = g.middlewares[i](ctrl)
+	}
+	if len(g.headers) != 0 {
+		ctrl = withHeaders(g.headers.Clone())(ctrl)
+	}
+	return ctrl
+}
+
+// GET registers a GET route, path being relative to the prefix of the group
+func (g *Group) GET(path string, ctrl Controller) *Route {
+	return g.router.GET(g.prefix+path, g.wrap(ctrl))
+}
+
+// HEAD registers a HEAD route, path being relative to the prefix of the group
+func (g *Group) HEAD(path string, ctrl Controller) *Route {
+	return g.router.HEAD(g.prefix+path, g.wrap(ctrl))
+}
+
+// POST registers a POST route, path being relative to the prefix of the group
+func (g *Group) POST(path string, ctrl Controller) *Route {
+	return g.router.POST(g.prefix+path, g.wrap(ctrl))
+}
+
+// PUT registers a PUT route, path being relative to the prefix of the group
+func (g *Group) PUT(path string, ctrl Controller) *Route {
+	return g.router.PUT(g.prefix+path, g.wrap(ctrl))
+}
+
+// DELETE registers a DELETE route, path being relative to the prefix of the group
+func (g *Group) DELETE(path string, ctrl Controller) *Route {
+	return g.router.DELETE(g.prefix+path, g.wrap(ctrl))
+}
@@ -0,0 +1,127 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// grpcCodes maps the gRPC status codes to their names, as used by Connect
+var grpcCodes = []string{
+	"ok",
+	"canceled",
+	"unknown",
+	"invalid_argument",
+	"deadline_exceeded",
+	"not_found",
+	"already_exists",
+	"permission_denied",
+	"resource_exhausted",
+	"failed_precondition",
+	"aborted",
+	"out_of_range",
+	"unimplemented",
+	"internal",
+	"unavailable",
+	"data_loss",
+	"unauthenticated",
+}
+
+// grpcStatuses maps the gRPC status codes to HTTP statuses, as done by grpc-gateway
+var grpcStatuses = []int{
+	200, // OK
+	499, // Canceled
+	500, // Unknown
+	400, // InvalidArgument
+	504, // DeadlineExceeded
+	404, // NotFound
+	409, // AlreadyExists
+	403, // PermissionDenied
+	429, // ResourceExhausted
+	400, // FailedPrecondition
+	409, // Aborted
+	400, // OutOfRange
+	501, // Unimplemented
+	500, // Internal
+	503, // Unavailable
+	500, // DataLoss
+	401, // Unauthenticated
+}
+
+// HTTPStatusFromGRPC returns the HTTP status corresponding to a gRPC status code
+func HTTPStatusFromGRPC(code int) int {
+	if code < 0 || code >= len(grpcStatuses) {
+		return 500
+	}
+	return grpcStatuses[code]
+}
+
+// GRPCError converts a gRPC status to an APIError. Internal errors are replaced by the default 500 message. The OK and
+// unknown codes are converted as Unknown, a 500, as they can't describe an error.
+func GRPCError(code int, message string) APIError {
+	status := HTTPStatusFromGRPC(code)
+	if code == 0 {
+		status = 500
+	}
+	if status >= 500 && status != 503 && status != 504 {
+		message = NewError500().Message
+	}
+	return NewAPIError(status, message)
+}
+
+// grpcStatus is the JSON error body of grpc-gateway (numeric code) and Connect (code name)
+type grpcStatus struct {
+	Code    json.RawMessage `json:"code"`
+	Message string          `json:"message"`
+}
+
+func (s grpcStatus) code() (int, bool) {
+	var code int
+	var name string
+
+	if json.Unmarshal(s.Code, &code) == nil {
+		return code, true
+	}
+	if json.Unmarshal(s.Code, &name) == nil {
+		for i := range grpcCodes {
+			if grpcCodes[i] == name {
+				return i, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// GRPCController adapts a grpc-gateway or Connect handler (or any http.Handler) to a Controller, so that it runs
+// behind the middlewares of the router. The gRPC errors are translated to APIError.
+// The responses are buffered, streaming methods are not supported.
+func GRPCController(h http.Handler) Controller {
+	return func(r *http.Request, p Params) (interface{}, error) {
+		w := serveBuffered(h, r)
+		if w.code >= 400 && strings.Contains(w.h.Get("Content-Type"), "json") {
+			var status grpcStatus
+			if json.Unmarshal(w.body.Bytes(), &status) == nil {
+				if code, ok := status.code(); ok == true {
+					return nil, GRPCError(code, status.Message)
+				}
+			}
+		}
+		return w.resp(), nil
+	}
+}
+
+// MountGRPC serves all the paths under prefix with a grpc-gateway or Connect handler, see GRPCController.
+// The handler receives the full path of the requests.
+func (r *Router) MountGRPC(prefix string, h http.Handler) {
+	r.Group(prefix).MountGRPC("", h)
+}
+
+// MountGRPC serves all the paths under prefix, relative to the prefix of the group, with a grpc-gateway or Connect
+// handler behind the middlewares of the group, see GRPCController.
+func (g *Group) MountGRPC(prefix string, h http.Handler) {
+	ctrl := g.wrap(GRPCController(h))
+	path := g.prefix + strings.TrimSuffix(prefix, "/") + "/*grpcpath"
+	for _, method := range []string{"GET", "POST", "PUT", "PATCH", "DELETE"} {
+		g.router.handleController(method, path, ctrl)
+	}
+}
@@ -0,0 +1,25 @@
+package rest
+
+import "testing"
+
+func TestGRPCError(t *testing.T) {
+	tests := []struct {
+		code    int
+		status  int
+		message string
+	}{
+		{0, 500, NewError500().Message},
+		{-1, 500, NewError500().Message},
+		{42, 500, NewError500().Message},
+		{2, 500, NewError500().Message},
+		{5, 404, "no such user"},
+		{14, 503, "no such user"},
+		{16, 401, "no such user"},
+	}
+	for _, test := range tests {
+		err := GRPCError(test.code, "no such user")
+		if err.StatusCode() != test.status || err.Message != test.message {
+			t.Errorf("code %d: got %d %q", test.code, err.StatusCode(), err.Message)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package rest
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"reflect"
+)
+
+// HALLink is a link of a HAL resource
+type HALLink struct {
+	Href      string `json:"href"`
+	Templated bool   `json:"templated,omitempty"`
+	Title     string `json:"title,omitempty"`
+}
+
+// HALResource wraps a value with its links and embedded resources.
+// It is encoded as a HAL document (application/hal+json) when the client asks for it through the Accept header,
+// and as the bare value otherwise.
+type HALResource struct {
+	Value    interface{}
+	Links    map[string]HALLink
+	Embedded map[string]interface{}
+}
+
+// NewHALResource creates a HALResource linking to itself with self
+func NewHALResource(v interface{}, self string) *HALResource {
+	return &HALResource{
+		Value: v,
+		Links: map[string]HALLink{
+			"self": HALLink{Href: self},
+		},
+		Embedded: make(map[string]interface{}),
+	}
+}
+
+// Link adds a link to the resource
+func (h *HALResource) Link(rel, href string) *HALResource {
+	h.Links[rel] = HALLink{Href: href}
+	return h
+}
+
+// Embed embeds resources under rel, they can be HALResource too. A single resource is embedded as an object, several
+// as an array.
+func (h *HALResource) Embed(rel string, resources ...interface{}) *HALResource {
+	if len(resources) == 1 {
+		h.Embedded[rel] = resources[0]
+	} else {
+		h.Embedded[rel] = resources
+	}
+	return h
+}
+
+// MarshalJSON encodes the bare value
+func (h HALResource) MarshalJSON() ([]byte, error) {
+	return json.Marshal(h.Value)
+}
+
+// MarshalXML encodes the bare value
+func (h HALResource) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return e.Encode(h.Value)
+}
+
+func marshalHAL(data interface{}) ([]byte, error) {
+	doc, err := toHAL(data)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
+
+// toHAL converts the HALResource found in v to HAL documents, other values are returned as is
+func toHAL(v interface{}) (interface{}, error) {
+	var h HALResource
+
+	if resource, ok := v.(*HALResource); ok == true && resource != nil {
+		h = *resource
+	} else if resource, ok := v.(HALResource); ok == true {
+		h = resource
+	} else {
+		val := reflect.ValueOf(v)
+		if val.Kind() != reflect.Slice || val.Type().Elem().Kind() == reflect.Uint8 {
+			return v, nil
+		}
+		docs := make([]interface{}, val.Len())
+		for i := range docs {
+			doc, err := toHAL(val.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			docs[i] = doc
+		}
+		return docs, nil
+	}
+
+	doc := make(map[string]interface{})
+	chunk, err := json.Marshal(h.Value)
+	if err != nil {
+		return nil, err
+	}
+	if string(chunk) != "null" {
+		var fields map[string]json.RawMessage
+		err = json.Unmarshal(chunk, &fields)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range fields {
+			doc[key] = value
+		}
+	}
+	if len(h.Links) != 0 {
+		doc["_links"] = h.Links
+	}
+	if len(h.Embedded) != 0 {
+		embedded := make(map[string]interface{})
+		for rel, resource := range h.Embedded {
+			embedded[rel], err = toHAL(resource)
+			if err != nil {
+				return nil, err
+			}
+		}
+		doc["_embedded"] = embedded
+	}
+	return doc, nil
+}
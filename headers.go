@@ -0,0 +1,43 @@
+package rest
+
+import (
+	"net/http"
+)
+
+// DefaultHeaders sets headers added to all the responses of the router (Server, API version, cache policy...),
+// including the errors and the not found responses. The controllers and the groups can overwrite them.
+func (r *Router) DefaultHeaders(h http.Header) {
+	r.mu.Lock()
+	r.defaultHeaders = h.Clone()
+	r.mu.Unlock()
+}
+
+// DefaultHeaders sets headers added to the responses of the routes of the group, overwriting the default headers of
+// the router. They only apply to the routes registered afterwards, and are inherited by the sub-groups.
+func (g *Group) DefaultHeaders(h http.Header) {
+	if g.headers == nil {
+		g.headers = make(http.Header)
+	}
+	for name, values := range h {
+		g.headers[name] = append([]string{}, values...)
+	}
+}
+
+// setHeaders sets the headers of h on w, overwriting the existing values
+func setHeaders(w http.ResponseWriter, h http.Header) {
+	for name, values := range h {
+		w.Header()[name] = append([]string{}, values...)
+	}
+}
+
+// withHeaders sets the headers h before running the controller
+func withHeaders(h http.Header) Middleware {
+	return func(next Controller) Controller {
+		return func(r *http.Request, p Params) (interface{}, error) {
+			if w := stateFromRequest(r).w; w != nil {
+				setHeaders(w, h)
+			}
+			return next(r, p)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// RouteDecl declares a route served by a method of a controller struct
+type RouteDecl struct {
+	Method     string
+	Path       string
+	Controller Controller
+}
+
+// RoutesDeclarer is implemented by the controller structs declaring their routes programmatically
+type RoutesDeclarer interface {
+	Routes() []RouteDecl
+}
+
+// Register registers the routes of controller structs, whose dependencies (DB, services...) are provided by the
+// caller through their fields:
+//
+//	router.Register(&UserController{DB: db})
+//
+// The routes are either returned by a Routes method (see RoutesDeclarer) or declared with route tags, giving the
+// HTTP method, the path and the name of the method serving it, which must have the signature of a Controller:
+//
+//	type UserController struct {
+//		DB *sql.DB
+//
+//		_ struct{} `route:"GET /users/:id Get"`
+//		_ struct{} `route:"POST /users Create"`
+//	}
+func (r *Router) Register(controllers ...interface{}) error {
+	for _, controller := range controllers {
+		decls, err := routeDecls(controller)
+		if err != nil {
+			return err
+		}
+		for _, decl := range decls {
+			r.handleController(strings.ToUpper(decl.Method), decl.Path, decl.Controller)
+		}
+	}
+	return nil
+}
+
+func routeDecls(controller interface{}) ([]RouteDecl, error) {
+	if declarer, ok := controller.(RoutesDeclarer); ok == true {
+		return declarer.Routes(), nil
+	}
+
+	val := reflect.ValueOf(controller)
+	t := val.Type()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot register %T: not a struct", controller)
+	}
+	var decls []RouteDecl
+	for i := 0; i < t.NumField(); i++ {
+		tag, ok := t.Field(i).Tag.Lookup("route")
+		if ok == false {
+			continue
+		}
+		parts := strings.Fields(tag)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("cannot register %T: malformed route tag %q, expected \"METHOD /path Method\"", controller, tag)
+		}
+		method := val.MethodByName(parts[2])
+		if method.IsValid() == false {
+			return nil, fmt.Errorf("cannot register %T: no method %s", controller, parts[2])
+		}
+		fn, ok := method.Interface().(func(*http.Request, Params) (interface{}, error))
+		if ok == false {
+			return nil, fmt.Errorf("cannot register %T: method %s is not a Controller", controller, parts[2])
+		}
+		decls = append(decls, RouteDecl{
+			Method:     parts[0],
+			Path:       parts[1],
+			Controller: fn,
+		})
+	}
+	return decls, nil
+}
@@ -0,0 +1,344 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// JSONAPIMode is a Middleware making the routes of a group speak JSON:API (application/vnd.api+json), whatever the
+// Accept header. Without it, JSON:API is only used when requested through the Accept header.
+//
+// The resources are described with jsonapi tags:
+//
+//	type Article struct {
+//		ID     int     `jsonapi:"primary,articles"`
+//		Title  string  `jsonapi:"attr,title"`
+//		Author *Person `jsonapi:"relation,author"`
+//	}
+//
+// The related resources are added to the included documents when requested with the include query parameter, and
+// the attributes can be filtered with sparse fieldsets (fields[articles]=title).
+func JSONAPIMode(next Controller) Controller {
+	return func(r *http.Request, p Params) (interface{}, error) {
+		stateFromRequest(r).jsonAPI = true
+		return next(r, p)
+	}
+}
+
+// JSONAPIError is a JSON:API error object
+type JSONAPIError struct {
+	Status string `json:"status"`
+	Title  string `json:"title,omitempty"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type jsonAPIIdentifier struct {
+	Type string `json:"type"`
+	ID   string `json:"id,omitempty"`
+}
+
+type jsonAPIRelationship struct {
+	Data interface{} `json:"data"`
+}
+
+type jsonAPIResource struct {
+	Type          string                         `json:"type"`
+	ID            string                         `json:"id,omitempty"`
+	Attributes    map[string]interface{}         `json:"attributes,omitempty"`
+	Relationships map[string]jsonAPIRelationship `json:"relationships,omitempty"`
+}
+
+type jsonAPIDocument struct {
+	Data     interface{}       `json:"data"`
+	Included []jsonAPIResource `json:"included,omitempty"`
+}
+
+type jsonAPIErrors struct {
+	Errors []JSONAPIError `json:"errors"`
+}
+
+// jsonAPIEncoder encodes the resources of a document, according to the include and fields query parameters
+type jsonAPIEncoder struct {
+	include  map[string]bool
+	fields   map[string]map[string]bool
+	included []jsonAPIResource
+	seen     map[jsonAPIIdentifier]bool
+}
+
+func marshalJSONAPI(r *http.Request, code int, data interface{}) ([]byte, error) {
+	if err, ok := data.(Error); ok == true && code >= 400 {
+		return json.Marshal(jsonAPIErrors{[]JSONAPIError{newJSONAPIError(err)}})
+	}
+
+	e := &jsonAPIEncoder{
+		include: make(map[string]bool),
+		fields:  make(map[string]map[string]bool),
+		seen:    make(map[jsonAPIIdentifier]bool),
+	}
+	query := r.URL.Query()
+	for _, include := range strings.Split(query.Get("include"), ",") {
+		if include != "" {
+			e.include[include] = true
+		}
+	}
+	for key, values := range query {
+		if strings.HasPrefix(key, "fields[") && strings.HasSuffix(key, "]") && len(values) != 0 {
+			fields := make(map[string]bool)
+			for _, field := range strings.Split(values[0], ",") {
+				fields[field] = true
+			}
+			e.fields[key[len("fields["):len(key)-1]] = fields
+		}
+	}
+
+	doc := jsonAPIDocument{}
+	val := reflect.ValueOf(data)
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			break
+		}
+		val = val.Elem()
+	}
+	if val.Kind() == reflect.Slice || val.Kind() == reflect.Array {
+		resources := make([]jsonAPIResource, 0, val.Len())
+		for i := 0; i < val.Len(); i++ {
+			resource, err := e.resource(val.Index(i), true)
+			if err != nil {
+				return nil, err
+			}
+			resources = append(resources, resource)
+		}
+		doc.Data = resources
+	} else if val.IsValid() && (val.Kind() != reflect.Ptr || val.IsNil() == false) {
+		resource, err := e.resource(val, true)
+		if err != nil {
+			return nil, err
+		}
+		doc.Data = resource
+	}
+	doc.Included = e.included
+	return json.Marshal(doc)
+}
+
+func newJSONAPIError(err Error) JSONAPIError {
+	jsonAPIErr := JSONAPIError{
+		Status: strconv.Itoa(err.StatusCode()),
+		Title:  http.StatusText(err.StatusCode()),
+	}
+	if err2, ok := err.(error); ok == true {
+		jsonAPIErr.Detail = err2.Error()
+	}
+	return jsonAPIErr
+}
+
+// resource encodes a struct described with jsonapi tags. The relations are only encoded for the primary data, the
+// included resources have no relationships, so that the cyclic graphs (an author of an article having articles...)
+// are encoded.
+func (e *jsonAPIEncoder) resource(val reflect.Value, primary bool) (jsonAPIResource, error) {
+	var resource jsonAPIResource
+
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return resource, fmt.Errorf("cannot encode %s as a JSON:API resource", val.Kind())
+	}
+	t := val.Type()
+	for i := 0; i < t.NumField(); i++ {
+		kind, name := jsonAPITag(t.Field(i))
+		field := val.Field(i)
+		if kind == "primary" {
+			resource.Type = name
+			resource.ID = fmt.Sprint(field.Interface())
+		}
+	}
+	if resource.Type == "" {
+		return resource, fmt.Errorf("cannot encode %s as a JSON:API resource: no primary field", t)
+	}
+
+	fields, sparse := e.fields[resource.Type]
+	for i := 0; i < t.NumField(); i++ {
+		kind, name := jsonAPITag(t.Field(i))
+		field := val.Field(i)
+		if kind == "" || kind == "primary" || (sparse == true && fields[name] == false) {
+			continue
+		}
+		if kind == "relation" && primary == false {
+			continue
+		}
+		if kind == "attr" {
+			if resource.Attributes == nil {
+				resource.Attributes = make(map[string]interface{})
+			}
+			resource.Attributes[name] = field.Interface()
+		} else if kind == "relation" {
+			relationship, err := e.relationship(field, primary && e.include[name])
+			if err != nil {
+				return resource, err
+			}
+			if resource.Relationships == nil {
+				resource.Relationships = make(map[string]jsonAPIRelationship)
+			}
+			resource.Relationships[name] = relationship
+		}
+	}
+	return resource, nil
+}
+
+func (e *jsonAPIEncoder) relationship(field reflect.Value, include bool) (jsonAPIRelationship, error) {
+	if field.Kind() == reflect.Slice {
+		identifiers := make([]jsonAPIIdentifier, 0, field.Len())
+		for i := 0; i < field.Len(); i++ {
+			identifier, err := e.related(field.Index(i), include)
+			if err != nil {
+				return jsonAPIRelationship{}, err
+			}
+			identifiers = append(identifiers, identifier)
+		}
+		return jsonAPIRelationship{identifiers}, nil
+	}
+	if field.Kind() == reflect.Ptr && field.IsNil() {
+		return jsonAPIRelationship{nil}, nil
+	}
+	identifier, err := e.related(field, include)
+	if err != nil {
+		return jsonAPIRelationship{}, err
+	}
+	return jsonAPIRelationship{identifier}, nil
+}
+
+func (e *jsonAPIEncoder) related(val reflect.Value, include bool) (jsonAPIIdentifier, error) {
+	resource, err := e.resource(val, false)
+	if err != nil {
+		return jsonAPIIdentifier{}, err
+	}
+	identifier := jsonAPIIdentifier{resource.Type, resource.ID}
+	if include == true && e.seen[identifier] == false {
+		e.seen[identifier] = true
+		e.included = append(e.included, resource)
+	}
+	return identifier, nil
+}
+
+// jsonAPITag returns the kind and the name of the jsonapi tag of the field, the unexported fields are skipped
+func jsonAPITag(field reflect.StructField) (kind, name string) {
+	if field.PkgPath != "" {
+		return "", ""
+	}
+	tag := strings.SplitN(field.Tag.Get("jsonapi"), ",", 2)
+	if len(tag) != 2 {
+		return "", ""
+	}
+	return tag[0], tag[1]
+}
+
+type jsonAPIInput struct {
+	Data *struct {
+		Type          string                     `json:"type"`
+		ID            string                     `json:"id"`
+		Attributes    map[string]json.RawMessage `json:"attributes"`
+		Relationships map[string]struct {
+			Data json.RawMessage `json:"data"`
+		} `json:"relationships"`
+	} `json:"data"`
+}
+
+// unmarshalJSONAPI decodes a JSON:API document holding a single resource into a struct described with jsonapi tags.
+// Only the identifiers of the related resources are set.
+func unmarshalJSONAPI(chunk []byte, v interface{}) error {
+	var doc jsonAPIInput
+
+	val := reflect.ValueOf(v)
+	if val.Kind() != reflect.Ptr || val.IsNil() || val.Elem().Kind() != reflect.Struct {
+		return errors.New("cannot parse JSON:API document to non-pointer to struct types")
+	}
+	err := json.Unmarshal(chunk, &doc)
+	if err != nil {
+		return err
+	}
+	if doc.Data == nil {
+		return errors.New("missing primary data")
+	}
+	val = val.Elem()
+	t := val.Type()
+	for i := 0; i < t.NumField(); i++ {
+		kind, name := jsonAPITag(t.Field(i))
+		field := val.Field(i)
+		if kind == "primary" {
+			if doc.Data.Type != name {
+				return NewAPIError(409, "unexpected resource type: "+doc.Data.Type)
+			}
+			if doc.Data.ID != "" {
+				err = setJSONAPIID(field, doc.Data.ID)
+			}
+		} else if kind == "attr" {
+			if raw, ok := doc.Data.Attributes[name]; ok == true {
+				err = json.Unmarshal(raw, field.Addr().Interface())
+			}
+		} else if kind == "relation" {
+			if relationship, ok := doc.Data.Relationships[name]; ok == true {
+				err = setJSONAPIRelation(field, relationship.Data)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setJSONAPIID(field reflect.Value, id string) error {
+	if field.Kind() == reflect.String {
+		field.SetString(id)
+		return nil
+	}
+	return json.Unmarshal([]byte(id), field.Addr().Interface())
+}
+
+func setJSONAPIRelation(field reflect.Value, raw json.RawMessage) error {
+	var identifiers []jsonAPIIdentifier
+
+	if field.Kind() == reflect.Slice {
+		err := json.Unmarshal(raw, &identifiers)
+		if err != nil {
+			return err
+		}
+		slice := reflect.MakeSlice(field.Type(), len(identifiers), len(identifiers))
+		for i := range identifiers {
+			err = setJSONAPIRelated(slice.Index(i), identifiers[i].ID)
+			if err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+	var identifier *jsonAPIIdentifier
+	err := json.Unmarshal(raw, &identifier)
+	if err != nil || identifier == nil {
+		return err
+	}
+	return setJSONAPIRelated(field, identifier.ID)
+}
+
+// setJSONAPIRelated sets the primary field of a related resource, allocating it if needed
+func setJSONAPIRelated(val reflect.Value, id string) error {
+	if val.Kind() == reflect.Ptr {
+		val.Set(reflect.New(val.Type().Elem()))
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return fmt.Errorf("cannot set relation of type %s", val.Type())
+	}
+	for i := 0; i < val.NumField(); i++ {
+		if kind, _ := jsonAPITag(val.Type().Field(i)); kind == "primary" {
+			return setJSONAPIID(val.Field(i), id)
+		}
+	}
+	return fmt.Errorf("cannot set relation of type %s: no primary field", val.Type())
+}
@@ -0,0 +1,56 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+type jsonAPIArticle struct {
+	ID     int            `jsonapi:"primary,articles"`
+	Title  string         `jsonapi:"attr,title"`
+	Author *jsonAPIPerson `jsonapi:"relation,author"`
+}
+
+type jsonAPIPerson struct {
+	ID       int               `jsonapi:"primary,people"`
+	Name     string            `jsonapi:"attr,name"`
+	Articles []*jsonAPIArticle `jsonapi:"relation,articles"`
+	password string            `jsonapi:"attr,password"`
+}
+
+func TestMarshalJSONAPICycle(t *testing.T) {
+	article := &jsonAPIArticle{ID: 1, Title: "Cycles"}
+	author := &jsonAPIPerson{ID: 2, Name: "Ada", Articles: []*jsonAPIArticle{article}, password: "secret"}
+	article.Author = author
+
+	tests := []struct {
+		name     string
+		query    string
+		data     interface{}
+		included int
+	}{
+		{"article", "", article, 0},
+		{"article with author", "?include=author", article, 1},
+		{"author with articles", "?include=articles", author, 1},
+		{"list", "?include=author", []*jsonAPIArticle{article, article}, 1},
+	}
+	for _, test := range tests {
+		chunk, err := marshalJSONAPI(httptest.NewRequest("GET", "/"+test.query, nil), 200, test.data)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		var doc struct {
+			Included []map[string]interface{} `json:"included"`
+		}
+		if err = json.Unmarshal(chunk, &doc); err != nil || len(doc.Included) != test.included {
+			t.Errorf("%s: got %s", test.name, chunk)
+		}
+		for _, resource := range doc.Included {
+			if _, ok := resource["relationships"]; ok == true {
+				t.Errorf("%s: the included resources have relationships: %s", test.name, chunk)
+			}
+		}
+	}
+}
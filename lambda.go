@@ -0,0 +1,228 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LambdaRequest is the event of an AWS Lambda function invoked by API Gateway (REST API, or HTTP API with the
+// payload format 1.0 or 2.0) or by an Application Load Balancer
+type LambdaRequest struct {
+	// Version is "2.0" for the HTTP API payload format 2.0, empty or "1.0" otherwise
+	Version string `json:"version,omitempty"`
+
+	// payload format 1.0 and ALB
+	HTTPMethod                      string              `json:"httpMethod,omitempty"`
+	Path                            string              `json:"path,omitempty"`
+	Headers                         map[string]string   `json:"headers,omitempty"`
+	MultiValueHeaders               map[string][]string `json:"multiValueHeaders,omitempty"`
+	QueryStringParameters           map[string]string   `json:"queryStringParameters,omitempty"`
+	MultiValueQueryStringParameters map[string][]string `json:"multiValueQueryStringParameters,omitempty"`
+
+	// payload format 2.0
+	RawPath        string   `json:"rawPath,omitempty"`
+	RawQueryString string   `json:"rawQueryString,omitempty"`
+	Cookies        []string `json:"cookies,omitempty"`
+
+	Body            string               `json:"body,omitempty"`
+	IsBase64Encoded bool                 `json:"isBase64Encoded"`
+	RequestContext  LambdaRequestContext `json:"requestContext"`
+}
+
+// LambdaRequestContext is the part of the request context of the Lambda events used by the adapter
+type LambdaRequestContext struct {
+	RequestID  string `json:"requestId,omitempty"`
+	DomainName string `json:"domainName,omitempty"`
+	Identity   struct {
+		SourceIP string `json:"sourceIp,omitempty"`
+	} `json:"identity"`
+	HTTP struct {
+		Method   string `json:"method,omitempty"`
+		Path     string `json:"path,omitempty"`
+		SourceIP string `json:"sourceIp,omitempty"`
+	} `json:"http"`
+	ELB *struct {
+		TargetGroupArn string `json:"targetGroupArn,omitempty"`
+	} `json:"elb,omitempty"`
+}
+
+// LambdaResponse is the response of an AWS Lambda function to API Gateway or to an Application Load Balancer
+type LambdaResponse struct {
+	StatusCode        int                 `json:"statusCode"`
+	StatusDescription string              `json:"statusDescription,omitempty"`
+	Headers           map[string]string   `json:"headers,omitempty"`
+	MultiValueHeaders map[string][]string `json:"multiValueHeaders,omitempty"`
+	Cookies           []string            `json:"cookies,omitempty"`
+	Body              string              `json:"body"`
+	IsBase64Encoded   bool                `json:"isBase64Encoded"`
+}
+
+// LambdaHandler adapts h, typically a Router, to an AWS Lambda function handling the API Gateway and ALB events,
+// so that the same routes are served by a server and by Lambda:
+//
+//	lambda.Start(rest.LambdaHandler(router))
+//
+// The responses are buffered, and encoded in base64 unless they are text.
+func LambdaHandler(h http.Handler) func(ctx context.Context, event LambdaRequest) (LambdaResponse, error) {
+	return func(ctx context.Context, event LambdaRequest) (LambdaResponse, error) {
+		r, err := event.request(ctx)
+		if err != nil {
+			return LambdaResponse{StatusCode: 400, StatusDescription: "400 Bad Request", Body: err.Error()}, nil
+		}
+		return event.response(serveBuffered(h, r)), nil
+	}
+}
+
+// LambdaEvent returns the Lambda event of the request, nil if it is not served by LambdaHandler
+func LambdaEvent(r *http.Request) *LambdaRequest {
+	event, _ := r.Context().Value(lambdaKey).(*LambdaRequest)
+	return event
+}
+
+// request converts the event to an HTTP request
+func (event *LambdaRequest) request(ctx context.Context) (*http.Request, error) {
+	method, path, query, sourceIP := event.HTTPMethod, event.Path, "", event.RequestContext.Identity.SourceIP
+	if event.Version == "2.0" {
+		method, path, query = event.RequestContext.HTTP.Method, event.RawPath, event.RawQueryString
+		sourceIP = event.RequestContext.HTTP.SourceIP
+	} else {
+		query = event.query()
+	}
+	if path == "" {
+		path = "/"
+	}
+	u, err := url.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+	u.RawQuery = query
+
+	body := []byte(event.Body)
+	if event.IsBase64Encoded == true {
+		body, err = base64.StdEncoding.DecodeString(event.Body)
+		if err != nil {
+			return nil, err
+		}
+	}
+	r, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	if event.MultiValueHeaders != nil {
+		for name, values := range event.MultiValueHeaders {
+			for _, value := range values {
+				r.Header.Add(name, value)
+			}
+		}
+	} else {
+		for name, value := range event.Headers {
+			r.Header.Set(name, value)
+		}
+	}
+	if len(event.Cookies) != 0 {
+		r.Header.Set("Cookie", strings.Join(event.Cookies, "; "))
+	}
+	if r.Header.Get("X-Request-Id") == "" && event.RequestContext.RequestID != "" {
+		r.Header.Set("X-Request-Id", event.RequestContext.RequestID)
+	}
+	r.Host = r.Header.Get("Host")
+	if r.Host == "" {
+		r.Host = event.RequestContext.DomainName
+	}
+	if sourceIP != "" {
+		r.RemoteAddr = net.JoinHostPort(sourceIP, "0")
+	}
+	r.ContentLength = int64(len(body))
+	r.RequestURI = u.RequestURI()
+	return r.WithContext(context.WithValue(ctx, lambdaKey, event)), nil
+}
+
+// query returns the query string of a payload format 1.0 or ALB event. API Gateway decodes the parameters, the
+// ALB does not.
+func (event *LambdaRequest) query() string {
+	params := event.MultiValueQueryStringParameters
+	if params == nil && event.QueryStringParameters != nil {
+		params = make(map[string][]string, len(event.QueryStringParameters))
+		for name, value := range event.QueryStringParameters {
+			params[name] = []string{value}
+		}
+	}
+	if event.RequestContext.ELB == nil {
+		return url.Values(params).Encode()
+	}
+
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var parts []string
+	for _, name := range names {
+		for _, value := range params[name] {
+			parts = append(parts, name+"="+value)
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// response converts the buffered response in the format of the event
+func (event *LambdaRequest) response(w *bufferedResponseWriter) LambdaResponse {
+	resp := LambdaResponse{
+		StatusCode: w.code,
+	}
+	if event.RequestContext.ELB != nil {
+		resp.StatusDescription = strings.TrimSpace(strconv.Itoa(w.code) + " " + http.StatusText(w.code))
+	}
+
+	if event.Version == "2.0" {
+		resp.Cookies = w.h["Set-Cookie"]
+		w.h.Del("Set-Cookie")
+	}
+	if event.MultiValueHeaders != nil {
+		// the ALB and API Gateway reply with the multi-value headers when they sent them
+		resp.MultiValueHeaders = w.h
+	} else {
+		resp.Headers = make(map[string]string, len(w.h))
+		for name, values := range w.h {
+			if name == "Set-Cookie" && len(values) > 1 {
+				// the cookies can't be joined with commas, they contain some (Expires...)
+				resp.MultiValueHeaders = map[string][]string{name: values}
+				continue
+			}
+			resp.Headers[name] = strings.Join(values, ", ")
+		}
+	}
+
+	if isText(w.h) == true {
+		resp.Body = w.body.String()
+	} else {
+		resp.Body = base64.StdEncoding.EncodeToString(w.body.Bytes())
+		resp.IsBase64Encoded = true
+	}
+	return resp
+}
+
+// isText tells if the response is text, which Lambda can send without base64
+func isText(h http.Header) bool {
+	if h.Get("Content-Encoding") != "" && h.Get("Content-Encoding") != "identity" {
+		return false
+	}
+	ctype := strings.ToLower(h.Get("Content-Type"))
+	if ctype == "" {
+		return true
+	}
+	for _, text := range []string{"text/", "json", "xml", "javascript", "x-www-form-urlencoded"} {
+		if strings.Contains(ctype, text) == true {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Listen listens on addr, which is either:
+//
+//	host:port         a TCP address
+//	unix:/path/sock   a Unix domain socket, readable and writable by the user and the group of the process
+//	systemd           the first socket passed by systemd (socket activation)
+//	systemd:N         the Nth socket passed by systemd, from 0
+func Listen(addr string) (net.Listener, error) {
+	if strings.HasPrefix(addr, "unix:") == true {
+		return ListenUnix(strings.TrimPrefix(addr, "unix:"), 0660)
+	}
+	if addr == "systemd" || strings.HasPrefix(addr, "systemd:") == true {
+		n := 0
+		if addr != "systemd" {
+			var err error
+			n, err = strconv.Atoi(strings.TrimPrefix(addr, "systemd:"))
+			if err != nil {
+				return nil, fmt.Errorf("invalid systemd socket %q", addr)
+			}
+		}
+		listeners, err := SystemdListeners()
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 || n >= len(listeners) {
+			return nil, fmt.Errorf("systemd passed %d sockets, no socket %d", len(listeners), n)
+		}
+		return listeners[n], nil
+	}
+	return net.Listen("tcp", addr)
+}
+
+// ListenUnix listens on the Unix domain socket path with the given permissions. A socket left by a previous process
+// is removed, the socket is removed once the listener is closed.
+func ListenUnix(path string, perm os.FileMode) (net.Listener, error) {
+	if info, err := os.Stat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if err = os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err = os.Chmod(path, perm); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
+
+var (
+	systemdOnce      sync.Once
+	systemdListeners []net.Listener
+	systemdErr       error
+)
+
+// SystemdListeners returns the sockets passed by systemd (socket activation, or inherited on restart), in order.
+// They are read once, the LISTEN_* variables being removed from the environment. It returns no listener if the
+// process was not started with sockets. Systemd sockets are not supported on Windows.
+func SystemdListeners() ([]net.Listener, error) {
+	systemdOnce.Do(func() {
+		systemdListeners, systemdErr = systemdFiles()
+	})
+	return systemdListeners, systemdErr
+}
+
+// ServeListener serves the router with srv on ln, see Serve
+func (r *Router) ServeListener(ctx context.Context, srv *http.Server, ln net.Listener) error {
+	r.mu.RLock()
+	tls := r.config.TLS
+	r.mu.RUnlock()
+	return r.serve(ctx, srv, func() error {
+		if srv.TLSConfig != nil {
+			return srv.ServeTLS(ln, "", "")
+		}
+		if tls.CertFile != "" {
+			return srv.ServeTLS(ln, tls.CertFile, tls.KeyFile)
+		}
+		return srv.Serve(ln)
+	})
+}
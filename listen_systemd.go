@@ -0,0 +1,50 @@
+//go:build !windows
+// +build !windows
+
+package rest
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// systemdFirstFD is the first file descriptor passed by the systemd socket activation
+const systemdFirstFD = 3
+
+// systemdFiles turns the file descriptors passed by systemd into listeners
+func systemdFiles() ([]net.Listener, error) {
+	var listeners []net.Listener
+
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count <= 0 {
+		return nil, nil
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	for fd := systemdFirstFD; fd < systemdFirstFD+count; fd++ {
+		syscall.CloseOnExec(fd)
+		name := "LISTEN_FD_" + strconv.Itoa(fd)
+		if i := fd - systemdFirstFD; i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		file := os.NewFile(uintptr(fd), name)
+		ln, err := net.FileListener(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("invalid systemd socket %s: %s", name, err)
+		}
+		listeners = append(listeners, ln)
+	}
+	return listeners, nil
+}
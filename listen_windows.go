@@ -0,0 +1,11 @@
+package rest
+
+import (
+	"errors"
+	"net"
+)
+
+// systemdFiles fails, systemd sockets are not supported on Windows
+func systemdFiles() ([]net.Listener, error) {
+	return nil, errors.New("systemd sockets are not supported on windows")
+}
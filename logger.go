@@ -0,0 +1,182 @@
+package rest
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Level is the severity of a log
+type Level int
+
+// The levels, from the least to the most severe
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	}
+	return "unknown"
+}
+
+// Logger receives the logs of a router, adapt the logging library of your choice to it
+type Logger interface {
+	Logf(level Level, format string, v ...interface{})
+}
+
+// StdLogger logs with the standard log package, the logs less severe than Level are dropped
+type StdLogger struct {
+	Level Level
+}
+
+// Logf logs if level is at least l.Level
+func (l StdLogger) Logf(level Level, format string, v ...interface{}) {
+	if level < l.Level {
+		return
+	}
+	log.Printf("["+level.String()+"] "+format, v...)
+}
+
+// SetLogger sets the logger of the router, nil restores the default one (StdLogger logging from LevelInfo)
+func (r *Router) SetLogger(l Logger) {
+	r.mu.Lock()
+	r.logger = l
+	r.mu.Unlock()
+}
+
+// getLogger returns the logger of the router, the default one if none was set or if r is nil
+func (r *Router) getLogger() Logger {
+	if r == nil {
+		return StdLogger{LevelInfo}
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.logger == nil {
+		return StdLogger{LevelInfo}
+	}
+	return r.logger
+}
+
+// ErrorLogging sets how the errors returned by the controllers are logged
+type ErrorLogging struct {
+	// ClientLevel is the level of the 4xx errors
+	ClientLevel Level
+	// ServerLevel is the level of the 5xx errors
+	ServerLevel Level
+	// Stack adds to the logs of the 5xx errors the stack trace recorded by the error when it was created, for the errors
+	// recording one (github.com/pkg/errors...)
+	Stack bool
+	// MaxPerSecond is the maximum number of errors logged per second and per class (4xx, 5xx), 0 for no limit.
+	// The number of dropped logs is logged once the second is elapsed.
+	MaxPerSecond int
+}
+
+// defaultErrorLogging logs the client errors as information and the server errors as errors
+var defaultErrorLogging = ErrorLogging{
+	ClientLevel: LevelInfo,
+	ServerLevel: LevelError,
+}
+
+// SetErrorLogging sets how the errors returned by the controllers are logged
+func (r *Router) SetErrorLogging(cfg ErrorLogging) {
+	r.mu.Lock()
+	r.errorLogging = &errorLogger{ErrorLogging: cfg}
+	r.mu.Unlock()
+}
+
+// errorLogger logs the errors, sampling them once MaxPerSecond is reached
+type errorLogger struct {
+	ErrorLogging
+
+	mu      sync.Mutex
+	second  int64
+	logged  [2]int
+	dropped [2]int
+}
+
+// allow returns whether an error of the class can be logged, and the number of logs dropped during the previous
+// second
+func (l *errorLogger) allow(class int) (bool, [2]int) {
+	var dropped [2]int
+	if l.MaxPerSecond <= 0 {
+		return true, dropped
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now().Unix()
+	if now != l.second {
+		dropped = l.dropped
+		l.second = now
+		l.logged = [2]int{}
+		l.dropped = [2]int{}
+	}
+	if l.logged[class] >= l.MaxPerSecond {
+		l.dropped[class]++
+		return false, dropped
+	}
+	l.logged[class]++
+	return true, dropped
+}
+
+// logError logs an error returned by a controller, according to the status code sent to the client. The messages are
+// masked by the redactor of the router.
+func (r *Router) logError(req *http.Request, err error, code int, parent error) {
+	var l *errorLogger
+	if r != nil {
+		r.mu.RLock()
+		l = r.errorLogging
+		r.mu.RUnlock()
+	}
+	if l == nil {
+		l = &errorLogger{ErrorLogging: defaultErrorLogging}
+	}
+	logger := Log(req)
+
+	class := 0
+	level := l.ClientLevel
+	if code >= 500 {
+		class = 1
+		level = l.ServerLevel
+	}
+	ok, dropped := l.allow(class)
+	if dropped[0] != 0 {
+		logger.Logf(l.ClientLevel, "%d 4xx errors not logged\n", dropped[0])
+	}
+	if dropped[1] != 0 {
+		logger.Logf(l.ServerLevel, "%d 5xx errors not logged\n", dropped[1])
+	}
+	if ok == false {
+		return
+	}
+
+	// the messages may hold secrets (the queries of the database errors...)
+	rd := r.getRedactor()
+	msg := "status %d: %s\n"
+	args := []interface{}{code, rd.Body(err.Error())}
+	if parent != nil {
+		msg = "status %d: %s, %s\n"
+		args = append(args, rd.Body(fmt.Sprintf("%+v", parent)))
+	}
+	if class == 1 && l.Stack == true {
+		if stack := stackOf(err); stack != "" {
+			msg += "%s\n"
+			args = append(args, rd.Body(stack))
+		}
+	}
+	logger.Logf(level, msg, args...)
+}
@@ -0,0 +1,81 @@
+package rest
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Maintenance configures the maintenance mode of a Router.
+type Maintenance struct {
+	// RetryAfter is sent in the Retry-After header if not 0.
+	RetryAfter time.Duration
+	// Body is the response sent to the clients, encoded like any controller response.
+	// A 503 APIError is sent if nil.
+	Body interface{}
+	// Allow lists the paths still served during the maintenance (health checks, admin...).
+	// A path ending with * matches any path starting with it.
+	Allow []string
+}
+
+func (m *Maintenance) allows(path string) bool {
+	for _, allowed := range m.Allow {
+		if strings.HasSuffix(allowed, "*") {
+			if strings.HasPrefix(path, strings.TrimSuffix(allowed, "*")) {
+				return true
+			}
+		} else if path == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// StartMaintenance makes the router reply 503 to all the requests, except the ones allowed by m.
+// It can be called while the server is running, calling it again replaces the current configuration.
+func (r *Router) StartMaintenance(m Maintenance) {
+	r.mu.Lock()
+	r.maintenance = &m
+	r.mu.Unlock()
+}
+
+// StopMaintenance makes the router serve the requests normally again
+func (r *Router) StopMaintenance() {
+	r.mu.Lock()
+	r.maintenance = nil
+	r.mu.Unlock()
+}
+
+// InMaintenance tells whether the maintenance mode is on
+func (r *Router) InMaintenance() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.maintenance != nil
+}
+
+// serveMaintenance replies 503 if the maintenance mode is on and the request is not allowed, it returns true in that
+// case
+func (r *Router) serveMaintenance(w http.ResponseWriter, req *http.Request) bool {
+	r.mu.RLock()
+	m := r.maintenance
+	r.mu.RUnlock()
+	if m == nil || m.allows(req.URL.Path) == true {
+		return false
+	}
+
+	if m.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(m.RetryAfter/time.Second)))
+	}
+	var body interface{} = NewAPIError(503, "the service is under maintenance, please retry later")
+	if m.Body != nil {
+		body = m.Body
+	}
+	outputFormat, _ := getFormat(req, "Accept")
+	err := output(w, req, 503, body, outputFormat)
+	if err != nil {
+		log.Println("error while writing maintenance response:", err)
+	}
+	return true
+}
@@ -0,0 +1,40 @@
+package rest
+
+import (
+	"time"
+)
+
+// Metrics receives the metrics of a router, adapt the metrics library of your choice (Prometheus, StatsD...) to it
+type Metrics interface {
+	// Incr increments the counter name
+	Incr(name string, tags map[string]string)
+	// Timing records a duration
+	Timing(name string, d time.Duration, tags map[string]string)
+}
+
+// SetMetrics sets the receiver of the metrics of the router, nil disables them
+func (r *Router) SetMetrics(m Metrics) {
+	r.mu.Lock()
+	r.metrics = m
+	r.mu.Unlock()
+}
+
+// incr increments a counter if the router has metrics
+func (r *Router) incr(name string, tags map[string]string) {
+	r.mu.RLock()
+	m := r.metrics
+	r.mu.RUnlock()
+	if m != nil {
+		m.Incr(name, tags)
+	}
+}
+
+// timing records a duration if the router has metrics
+func (r *Router) timing(name string, d time.Duration, tags map[string]string) {
+	r.mu.RLock()
+	m := r.metrics
+	r.mu.RUnlock()
+	if m != nil {
+		m.Timing(name, d, tags)
+	}
+}
@@ -0,0 +1,84 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+)
+
+// mirrorSlots limits the number of mirrored requests in progress, the requests are not mirrored beyond it
+var mirrorSlots = make(chan struct{}, 256)
+
+// Mirror duplicates the requests of the route to h, asynchronously, its responses are ignored. It allows to
+// validate a new implementation against the production traffic (dark launch). The requests are mirrored with their
+// body, once the middlewares of the route accepted them.
+func (rt *Route) Mirror(h http.Handler) *Route {
+	return rt.update(func(options *RouteOptions) {
+		options.Mirror = h
+	})
+}
+
+// MirrorTo duplicates the requests of the route to upstream, see Mirror
+func (rt *Route) MirrorTo(upstream *url.URL) *Route {
+	return rt.Mirror(httputil.NewSingleHostReverseProxy(upstream))
+}
+
+// errReader returns err once read
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+// mirrored mirrors the requests to h before running next
+func mirrored(h http.Handler, next Controller) Controller {
+	return func(r *http.Request, p Params) (interface{}, error) {
+		mirror(h, r)
+		return next(r, p)
+	}
+}
+
+// mirror sends a copy of the request to h in the background
+func mirror(h http.Handler, r *http.Request) {
+	var body []byte
+
+	if r.Body != nil && r.Body != http.NoBody {
+		var err error
+		body, err = ioutil.ReadAll(r.Body)
+		if err != nil {
+			// the controller gets the error, the request is not mirrored
+			r.Body = readCloser{io.MultiReader(bytes.NewReader(body), errReader{err}), r.Body}
+			return
+		}
+		r.Body = readCloser{bytes.NewReader(body), r.Body}
+	}
+	select {
+	case mirrorSlots <- struct{}{}:
+	default:
+		Log(r).Logf(LevelWarn, "too many mirrored requests in progress, %s %s not mirrored\n", r.Method, r.URL.Path)
+		return
+	}
+
+	// the mirrored request must outlive the request
+	clone := r.Clone(context.Background())
+	clone.Body = ioutil.NopCloser(bytes.NewReader(body))
+	clone.RequestURI = ""
+	if router := routerFromRequest(r); router != nil {
+		router.incr("rest.requests.mirrored", stateFromRequest(r).tags())
+	}
+	go func() {
+		defer func() {
+			<-mirrorSlots
+			if rcv := recover(); rcv != nil && rcv != http.ErrAbortHandler {
+				Log(r).Logf(LevelError, "mirror of %s %s panicked: %v\n", r.Method, r.URL.Path, rcv)
+			}
+		}()
+		serveBuffered(h, clone)
+	}()
+}
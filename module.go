@@ -0,0 +1,119 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Module is a reusable set of routes, middlewares and health checks.
+// A module may also implement ModuleInitializer, ModuleCloser, HealthChecker and fmt.Stringer.
+type Module interface {
+	Register(r *Router)
+}
+
+// ModuleInitializer is implemented by the modules needing an initialization, Init is called right before Register.
+// A module whose Init fails must release what it acquired, it is not closed.
+type ModuleInitializer interface {
+	Init() error
+}
+
+// ModuleCloser is implemented by the modules holding resources, Close is called by Router.Shutdown.
+type ModuleCloser interface {
+	Close() error
+}
+
+// HealthChecker is implemented by the modules able to report their health.
+type HealthChecker interface {
+	HealthCheck() error
+}
+
+// Install initializes and registers the modules in the given order.
+// It stops at the first failing initialization and returns its error, the failing module is neither registered nor
+// closed.
+func (r *Router) Install(modules ...Module) error {
+	for _, module := range modules {
+		if initializer, ok := module.(ModuleInitializer); ok == true {
+			err := initializer.Init()
+			if err != nil {
+				return fmt.Errorf("failed to initialize module %s: %s", moduleName(module), err)
+			}
+		}
+		module.Register(r)
+		r.mu.Lock()
+		r.modules = append(r.modules, module)
+		r.mu.Unlock()
+	}
+	return nil
+}
+
+// Shutdown closes the installed modules, in the reverse order of their installation.
+// All the modules are closed even if some of them fail, the first error is returned.
+func (r *Router) Shutdown() error {
+	var first error
+
+	r.mu.Lock()
+	modules := r.modules
+	r.modules = nil
+	r.mu.Unlock()
+	for i := len(modules) - 1; i >= 0; i-- {
+		if closer, ok := modules[i].(ModuleCloser); ok == true {
+			err := closer.Close()
+			if err != nil && first == nil {
+				first = fmt.Errorf("failed to close module %s: %s", moduleName(modules[i]), err)
+			}
+		}
+	}
+	return first
+}
+
+func moduleName(module Module) string {
+	if stringer, ok := module.(fmt.Stringer); ok == true {
+		return stringer.String()
+	}
+	return fmt.Sprintf("%T", module)
+}
+
+// HealthReport is the response of Router.Health
+type HealthReport struct {
+	Status  string
+	Modules map[string]string
+	code    int
+}
+
+// StatusCode returns 200 if all the modules are healthy, 503 otherwise
+func (h HealthReport) StatusCode() int {
+	return h.code
+}
+
+// Location returns an empty string
+func (h HealthReport) Location() string {
+	return ""
+}
+
+// Health is a Controller reporting the health of the installed modules, register it like any other controller:
+//
+//	router.GET("/health", router.Health)
+func (r *Router) Health(req *http.Request, p Params) (interface{}, error) {
+	report := HealthReport{
+		Status:  "ok",
+		Modules: make(map[string]string),
+		code:    200,
+	}
+	r.mu.RLock()
+	modules := r.modules
+	r.mu.RUnlock()
+	for _, module := range modules {
+		checker, ok := module.(HealthChecker)
+		if ok == false {
+			continue
+		}
+		if err := checker.HealthCheck(); err != nil {
+			report.Status = "unhealthy"
+			report.Modules[moduleName(module)] = err.Error()
+			report.code = 503
+		} else {
+			report.Modules[moduleName(module)] = "ok"
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,60 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type testModule struct {
+	path    string
+	initErr error
+	calls   []string
+}
+
+func (m *testModule) Register(r *Router) {
+	m.calls = append(m.calls, "register")
+	r.GET(m.path, func(*http.Request, Params) (interface{}, error) {
+		return "ok", nil
+	})
+}
+
+func (m *testModule) Init() error {
+	m.calls = append(m.calls, "init")
+	return m.initErr
+}
+
+func (m *testModule) Close() error {
+	m.calls = append(m.calls, "close")
+	return nil
+}
+
+func TestInstall(t *testing.T) {
+	tests := []struct {
+		name    string
+		initErr error
+		code    int
+		calls   string
+	}{
+		{"initialized", nil, 200, "init register close"},
+		{"failed", errors.New("unreachable"), 404, "init"},
+	}
+	for _, test := range tests {
+		m := &testModule{path: "/module", initErr: test.initErr}
+		r := New()
+		if err := r.Install(m); (err == nil) != (test.initErr == nil) {
+			t.Errorf("%s: got %v", test.name, err)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/module", nil))
+		if w.Code != test.code {
+			t.Errorf("%s: got %d, expected %d", test.name, w.Code, test.code)
+		}
+		r.Shutdown()
+		if calls := fmt.Sprint(m.calls); calls != "["+test.calls+"]" {
+			t.Errorf("%s: got the calls %s", test.name, calls)
+		}
+	}
+}
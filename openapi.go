@@ -0,0 +1,377 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// OpenAPI is an OpenAPI 3 document. Only the parts used to document and validate the routes are modeled.
+type OpenAPI struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       OpenAPIInfo          `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components *Components          `json:"components,omitempty"`
+}
+
+// OpenAPIInfo is the metadata of an OpenAPI document
+type OpenAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// Components holds the schemas referenced by the document
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas,omitempty"`
+}
+
+// PathItem describes the operations available on a path
+type PathItem struct {
+	Parameters []*Parameter `json:"parameters,omitempty"`
+	Get        *Operation   `json:"get,omitempty"`
+	Head       *Operation   `json:"head,omitempty"`
+	Post       *Operation   `json:"post,omitempty"`
+	Put        *Operation   `json:"put,omitempty"`
+	Patch      *Operation   `json:"patch,omitempty"`
+	Delete     *Operation   `json:"delete,omitempty"`
+	Options    *Operation   `json:"options,omitempty"`
+}
+
+// Operation describes a route
+type Operation struct {
+	OperationID string               `json:"operationId,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Parameter describes a path, query or header parameter
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema,omitempty"`
+}
+
+// RequestBody describes the body of a request
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response describes a response
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType describes a body for a content type
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// Schema is a JSON schema, as used by OpenAPI
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// LoadOpenAPI reads an OpenAPI document in JSON, it fails if the references of its schemas are cyclic
+func LoadOpenAPI(r io.Reader) (*OpenAPI, error) {
+	doc := new(OpenAPI)
+	err := json.NewDecoder(r).Decode(doc)
+	if err != nil {
+		return nil, err
+	}
+	if doc.Components != nil {
+		for _, schema := range doc.Components.Schemas {
+			if _, err = doc.resolve(schema); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return doc, nil
+}
+
+// operation returns the operation of the path item for method, nil if there is none
+func (p *PathItem) operation(method string) *Operation {
+	switch method {
+	case "GET":
+		return p.Get
+	case "HEAD":
+		return p.Head
+	case "POST":
+		return p.Post
+	case "PUT":
+		return p.Put
+	case "PATCH":
+		return p.Patch
+	case "DELETE":
+		return p.Delete
+	case "OPTIONS":
+		return p.Options
+	}
+	return nil
+}
+
+func (p *PathItem) setOperation(method string, op *Operation) {
+	switch method {
+	case "GET":
+		p.Get = op
+	case "HEAD":
+		p.Head = op
+	case "POST":
+		p.Post = op
+	case "PUT":
+		p.Put = op
+	case "PATCH":
+		p.Patch = op
+	case "DELETE":
+		p.Delete = op
+	case "OPTIONS":
+		p.Options = op
+	}
+}
+
+// find returns the operation matching the request path, with the values of the path parameters. The literal
+// segments are preferred over the templated ones (/users/me over /users/{id}), from the first segment on.
+func (doc *OpenAPI) find(method, path string) (*PathItem, *Operation, map[string]string) {
+	var best string
+	var bestScore []bool
+	var bestParams map[string]string
+
+	segments := strings.Split(path, "/")
+	for template, item := range doc.Paths {
+		if item.operation(method) == nil {
+			continue
+		}
+		parts := strings.Split(template, "/")
+		if len(parts) != len(segments) {
+			continue
+		}
+		params := make(map[string]string)
+		score := make([]bool, len(parts))
+		matched := true
+		for i := range parts {
+			if strings.HasPrefix(parts[i], "{") && strings.HasSuffix(parts[i], "}") {
+				params[parts[i][1:len(parts[i])-1]] = segments[i]
+			} else if parts[i] != segments[i] {
+				matched = false
+				break
+			} else {
+				score[i] = true
+			}
+		}
+		if matched == true && (bestScore == nil || moreLiteral(score, bestScore) == true ||
+			(equalScores(score, bestScore) == true && template < best)) {
+			best, bestScore, bestParams = template, score, params
+		}
+	}
+	if bestScore == nil {
+		return nil, nil, nil
+	}
+	item := doc.Paths[best]
+	return item, item.operation(method), bestParams
+}
+
+// moreLiteral tells if the path template whose literal segments are a has a literal segment before b
+func moreLiteral(a, b []bool) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i]
+		}
+	}
+	return false
+}
+
+func equalScores(a, b []bool) bool {
+	return moreLiteral(a, b) == false && moreLiteral(b, a) == false
+}
+
+// resolve follows the reference of a schema, it fails if the reference is invalid or cyclic
+func (doc *OpenAPI) resolve(s *Schema) (*Schema, error) {
+	var visited map[string]bool
+
+	for s != nil && s.Ref != "" {
+		if doc.Components == nil || strings.HasPrefix(s.Ref, "#/components/schemas/") == false {
+			return nil, nil
+		}
+		if visited[s.Ref] == true {
+			return nil, errors.New("cyclic reference " + s.Ref)
+		}
+		if visited == nil {
+			visited = make(map[string]bool)
+		}
+		visited[s.Ref] = true
+		s = doc.Components.Schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+	}
+	return s, nil
+}
+
+// OpenAPI generates an OpenAPI document from the registered routes. The path parameters are documented, the bodies
+// are left to the caller.
+func (r *Router) OpenAPI(title, version string) *OpenAPI {
+	doc := &OpenAPI{
+		OpenAPI: "3.0.3",
+		Info:    OpenAPIInfo{title, version},
+		Paths:   make(map[string]*PathItem),
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, rt := range r.routes {
+		template, params := openAPIPath(rt.path)
+		item, ok := doc.Paths[template]
+		if ok == false {
+			item = new(PathItem)
+			doc.Paths[template] = item
+		}
+		op := &Operation{
+			Responses: map[string]*Response{
+				"default": &Response{Description: "response"},
+			},
+		}
+		for _, param := range params {
+			op.Parameters = append(op.Parameters, &Parameter{
+				Name:     param,
+				In:       "path",
+				Required: true,
+				Schema:   &Schema{Type: "string"},
+			})
+		}
+		item.setOperation(rt.method, op)
+	}
+	return doc
+}
+
+// openAPIPath converts an httprouter path (/users/:id/*file) to an OpenAPI path (/users/{id}/{file})
+func openAPIPath(path string) (string, []string) {
+	var params []string
+
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		if strings.HasPrefix(part, ":") || strings.HasPrefix(part, "*") {
+			params = append(params, part[1:])
+			parts[i] = "{" + part[1:] + "}"
+		}
+	}
+	return strings.Join(parts, "/"), params
+}
+
+// SchemaOf generates the schema of the type of v. The properties of the structs are named after their json tags, the
+// fields of the embedded structs are promoted like by encoding/json. The recursive types are described once, their
+// nested occurrences being plain objects.
+func SchemaOf(v interface{}) *Schema {
+	return schemaOf(reflect.TypeOf(v), make(map[reflect.Type]bool))
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaOf returns the schema of t, inProgress holds the structs being described
+func schemaOf(t reflect.Type, inProgress map[reflect.Type]bool) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+	if t.Kind() == reflect.Ptr {
+		s := schemaOf(t.Elem(), inProgress)
+		s.Nullable = true
+		return s
+	}
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: schemaOf(t.Elem(), inProgress)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: schemaOf(t.Elem(), inProgress)}
+	case reflect.Struct:
+		s := &Schema{Type: "object"}
+		if inProgress[t] == true {
+			return s
+		}
+		inProgress[t] = true
+		s.Properties = make(map[string]*Schema)
+		structProperties(t, s.Properties, inProgress)
+		delete(inProgress, t)
+		return s
+	}
+	return &Schema{}
+}
+
+// structProperties adds the properties of the fields of the struct t to properties. The fields of the embedded
+// structs without json name are promoted, the fields of the outer struct taking precedence.
+func structProperties(t reflect.Type, properties map[string]*Schema, inProgress map[reflect.Type]bool) {
+	var embedded []reflect.Type
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous == true && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded = append(embedded, ft)
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = schemaOf(field.Type, inProgress)
+		if tag, ok := field.Tag.Lookup("enum"); ok == true && properties[name].Type == "string" {
+			for _, value := range strings.Split(tag, ",") {
+				properties[name].Enum = append(properties[name].Enum, strings.TrimSpace(value))
+			}
+		}
+	}
+	for _, et := range embedded {
+		if inProgress[et] == true {
+			continue
+		}
+		inProgress[et] = true
+		promoted := make(map[string]*Schema)
+		structProperties(et, promoted, inProgress)
+		delete(inProgress, et)
+		for name, schema := range promoted {
+			if _, ok := properties[name]; ok == false {
+				properties[name] = schema
+			}
+		}
+	}
+}
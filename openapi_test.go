@@ -0,0 +1,171 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestOpenAPIFind(t *testing.T) {
+	op := &Operation{}
+	doc := &OpenAPI{Paths: map[string]*PathItem{
+		"/users/{id}":        {Get: op},
+		"/users/me":          {Get: op},
+		"/users/{id}/posts":  {Get: op},
+		"/{kind}/me/posts":   {Get: op},
+		"/groups/{a}":        {Get: op},
+		"/groups/{b}":        {Get: op},
+		"/users/{id}/avatar": {Put: op},
+	}}
+	tests := []struct {
+		method   string
+		path     string
+		template string
+	}{
+		{"GET", "/users/me", "/users/me"},
+		{"GET", "/users/42", "/users/{id}"},
+		{"GET", "/users/me/posts", "/users/{id}/posts"},
+		{"GET", "/groups/1", "/groups/{a}"},
+		{"GET", "/users/42/avatar", ""},
+		{"GET", "/other", ""},
+	}
+	for _, test := range tests {
+		for i := 0; i < 20; i++ {
+			item, _, _ := doc.find(test.method, test.path)
+			if item != doc.Paths[test.template] {
+				t.Fatalf("%s %s: matched the wrong path, expected %q", test.method, test.path, test.template)
+			}
+		}
+	}
+}
+
+func TestOpenAPICyclicRef(t *testing.T) {
+	tests := []struct {
+		name    string
+		schemas string
+	}{
+		{"self", `{"A": {"$ref": "#/components/schemas/A"}}`},
+		{"loop", `{"A": {"$ref": "#/components/schemas/B"}, "B": {"$ref": "#/components/schemas/A"}}`},
+	}
+	for _, test := range tests {
+		_, err := LoadOpenAPI(strings.NewReader(`{"paths": {}, "components": {"schemas": ` + test.schemas + `}}`))
+		if err == nil {
+			t.Errorf("%s: the cyclic document was loaded", test.name)
+		}
+	}
+
+	doc := &OpenAPI{Components: &Components{Schemas: map[string]*Schema{
+		"A": {Ref: "#/components/schemas/A"},
+	}}}
+	if _, err := doc.resolve(&Schema{Ref: "#/components/schemas/A"}); err == nil {
+		t.Error("the cyclic reference was resolved")
+	}
+}
+
+func TestValidateAfterMiddlewares(t *testing.T) {
+	min := 3
+	doc := &OpenAPI{Paths: map[string]*PathItem{
+		"/items": {Post: &Operation{RequestBody: &RequestBody{Required: true, Content: map[string]*MediaType{
+			"application/json": {Schema: &Schema{Type: "object", Properties: map[string]*Schema{
+				"name": {Type: "string", MinLength: &min},
+			}}},
+		}}}},
+	}}
+	auth := func(next Controller) Controller {
+		return func(r *http.Request, p Params) (interface{}, error) {
+			if r.Header.Get("Authorization") == "" {
+				return nil, NewAPIError(401, "unauthorized")
+			}
+			return next(r, p)
+		}
+	}
+	router := New()
+	router.ValidateRequests(doc)
+	router.POST("/items", func(r *http.Request, p Params) (interface{}, error) {
+		return "ok", nil
+	}).Use(auth).WithMaxBodySize(100)
+
+	tests := []struct {
+		name string
+		auth string
+		body string
+		code int
+	}{
+		{"unauthenticated", "", `{"name": "a"}`, 401},
+		{"invalid", "token", `{"name": "a"}`, 400},
+		{"too large", "token", `{"name": "` + strings.Repeat("a", 200) + `"}`, 413},
+		{"valid", "token", `{"name": "abc"}`, 200},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest("POST", "/items", strings.NewReader(test.body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		if test.auth != "" {
+			req.Header.Set("Authorization", test.auth)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != test.code {
+			t.Errorf("%s: got %d %s, expected %d", test.name, w.Code, w.Body.String(), test.code)
+		}
+	}
+}
+
+type schemaNode struct {
+	Name     string
+	Children []*schemaNode `json:"children"`
+	Parent   *schemaNode   `json:"parent"`
+}
+
+type schemaBase struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	private string
+}
+
+type schemaAudit struct {
+	CreatedBy string `json:"created_by"`
+}
+
+type schemaUser struct {
+	schemaBase
+	*schemaAudit
+	Name   string     `json:"name" enum:"a,b"`
+	Nested schemaBase `json:"nested"`
+	Friend *schemaUser
+}
+
+func TestSchemaOf(t *testing.T) {
+	tests := []struct {
+		name       string
+		v          interface{}
+		properties string
+	}{
+		{"recursive", schemaNode{}, "Name children parent"},
+		{"embedded", schemaUser{}, "Friend created_by id name nested"},
+	}
+	for _, test := range tests {
+		s := SchemaOf(test.v)
+		names := make([]string, 0, len(s.Properties))
+		for name := range s.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		if strings.Join(names, " ") != test.properties {
+			t.Errorf("%s: got the properties %v", test.name, names)
+		}
+	}
+
+	user := SchemaOf(schemaUser{})
+	if len(user.Properties["name"].Enum) != 2 {
+		t.Errorf("the outer field does not take precedence: %+v", user.Properties["name"])
+	}
+	if user.Properties["Friend"].Type != "object" || user.Properties["Friend"].Nullable == false {
+		t.Errorf("got %+v for the recursive field", user.Properties["Friend"])
+	}
+	if len(user.Properties["nested"].Properties) != 2 {
+		t.Errorf("got %+v for the nested struct", user.Properties["nested"])
+	}
+}
@@ -0,0 +1,21 @@
+package rest
+
+import (
+	"io"
+	"net/http"
+	"time"
+)
+
+// RespReaderAt is an interface allowing to return a byte stream of a known size.
+// Such responses honor the Range headers, replying 206 with the requested parts, which allows resumable downloads.
+type RespReaderAt interface {
+	ContentType() string
+	Size() int64
+	io.ReaderAt
+}
+
+// outputReaderAt writes a RespReaderAt, honoring the Range, If-Range and HEAD semantics.
+func outputReaderAt(w http.ResponseWriter, r *http.Request, resp RespReaderAt) {
+	w.Header().Set("Content-Type", resp.ContentType())
+	http.ServeContent(w, r, "", time.Time{}, io.NewSectionReader(resp, 0, resp.Size()))
+}
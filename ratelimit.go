@@ -0,0 +1,281 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimitStore counts the requests of the rate limiters. A store shared by the instances of the server (Redis,
+// memcache...) enforces the limits across all of them.
+type RateLimitStore interface {
+	// Incr increments the counter key and returns its new value, the counter expires after ttl
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	// Get returns the value of the counter key, 0 if it does not exist
+	Get(ctx context.Context, key string) (int64, error)
+}
+
+// RateLimiter limits the number of requests per key (client IP, user, tenant...) with a sliding window: the count of
+// the current window is added to the count of the previous one, weighted by the part of it still in the sliding
+// window.
+type RateLimiter struct {
+	Store RateLimitStore
+	// Key returns the key the requests are counted under, the client IP if nil. Return "user:"+id to limit per user,
+	// "tenant:"+id to limit per tenant...
+	Key func(r *http.Request) string
+	// Prefix is prepended to the keys in the store, in order to share it between several limiters
+	Prefix string
+
+	mu     sync.RWMutex
+	limit  int64
+	window time.Duration
+}
+
+// NewRateLimiter creates a RateLimiter allowing limit requests per window, in memory if store is nil. It panics if
+// the limit or the window is not positive.
+func NewRateLimiter(limit int, window time.Duration, store RateLimitStore) *RateLimiter {
+	if limit <= 0 || window <= 0 {
+		panic("the limit and the window of a rate limiter must be positive")
+	}
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	return &RateLimiter{
+		Store:  store,
+		Prefix: "ratelimit:",
+		limit:  int64(limit),
+		window: window,
+	}
+}
+
+// SetLimit changes the limit, it can be called while serving. It panics if the limit or the window is not positive.
+func (l *RateLimiter) SetLimit(limit int, window time.Duration) {
+	if limit <= 0 || window <= 0 {
+		panic("the limit and the window of a rate limiter must be positive")
+	}
+	l.mu.Lock()
+	l.limit = int64(limit)
+	l.window = window
+	l.mu.Unlock()
+}
+
+// Limit returns the number of requests allowed per window
+func (l *RateLimiter) Limit() (int, time.Duration) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return int(l.limit), l.window
+}
+
+// Allow counts a request of key and returns whether it is allowed, the number of requests remaining and, if it is
+// not allowed, when to retry
+func (l *RateLimiter) Allow(ctx context.Context, key string) (bool, int64, time.Duration, error) {
+	l.mu.RLock()
+	limit, window := l.limit, l.window
+	l.mu.RUnlock()
+	if window <= 0 {
+		return true, limit, 0, errors.New("the window of the rate limiter is not set")
+	}
+
+	now := time.Now()
+	current := now.UnixNano() / int64(window)
+	elapsed := float64(now.UnixNano()%int64(window)) / float64(window)
+	key = l.Prefix + key + ":" + strconv.FormatInt(int64(window), 36) + ":"
+	previous, err := l.Store.Get(ctx, key+strconv.FormatInt(current-1, 10))
+	if err != nil {
+		return true, limit, 0, err
+	}
+	count, err := l.Store.Incr(ctx, key+strconv.FormatInt(current, 10), 2*window)
+	if err != nil {
+		return true, limit, 0, err
+	}
+	estimate := int64(float64(previous)*(1-elapsed)) + count
+	if estimate <= limit {
+		return true, limit - estimate, 0, nil
+	}
+	retry := time.Duration((1 - elapsed) * float64(window))
+	if retry < time.Second {
+		retry = time.Second
+	}
+	return false, 0, retry, nil
+}
+
+// Middleware rejects the requests above the limit with a 429, the X-RateLimit-Limit and X-RateLimit-Remaining
+// headers are set on all the responses. The requests are allowed if the store fails. Without Key, the requests
+// whose client IP is unknown (served on a Unix socket...) fail with a 500 rather than sharing a limit.
+func (l *RateLimiter) Middleware(next Controller) Controller {
+	return func(r *http.Request, p Params) (interface{}, error) {
+		var key string
+		if l.Key != nil {
+			key = l.Key(r)
+		} else if ip := ClientIP(r); ip != nil {
+			key = ip.String()
+		} else {
+			err := errors.New("rate limiter: unknown client IP, set the Key of the limiter")
+			return nil, errorTransparent{NewError500(), err}
+		}
+		allowed, remaining, retry, err := l.Allow(r.Context(), key)
+		if err != nil {
+			Log(r).Logf(LevelError, "rate limiter store failed: %s\n", err)
+		}
+		if w := stateFromRequest(r).w; w != nil {
+			limit, _ := l.Limit()
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.FormatInt(remaining, 10))
+			if allowed == false {
+				w.Header().Set("Retry-After", seconds(retry))
+			}
+		}
+		if allowed == false {
+			return nil, NewAPIError(429, "too many requests")
+		}
+		return next(r, p)
+	}
+}
+
+// memoryStore is a RateLimitStore local to the process
+type memoryStore struct {
+	mu       sync.Mutex
+	counters map[string]memoryCounter
+	cleaned  time.Time
+}
+
+type memoryCounter struct {
+	value   int64
+	expires time.Time
+}
+
+// NewMemoryStore creates a RateLimitStore in memory, the limits are enforced per instance of the server
+func NewMemoryStore() RateLimitStore {
+	return &memoryStore{counters: make(map[string]memoryCounter)}
+}
+
+func (s *memoryStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if now.Sub(s.cleaned) > time.Minute {
+		for k, c := range s.counters {
+			if now.After(c.expires) == true {
+				delete(s.counters, k)
+			}
+		}
+		s.cleaned = now
+	}
+	c, ok := s.counters[key]
+	if ok == false || now.After(c.expires) == true {
+		c = memoryCounter{expires: now.Add(ttl)}
+	}
+	c.value++
+	s.counters[key] = c
+	return c.value, nil
+}
+
+func (s *memoryStore) Get(ctx context.Context, key string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.counters[key]
+	if ok == false || time.Now().After(c.expires) == true {
+		return 0, nil
+	}
+	return c.value, nil
+}
+
+// RedisDoer runs Redis commands, it is implemented by the clients of go-redis (Do(ctx, args...).Result()) and
+// redigo through a small adapter
+type RedisDoer interface {
+	Do(ctx context.Context, args ...interface{}) (interface{}, error)
+}
+
+// redisStore is a RateLimitStore in Redis
+type redisStore struct {
+	client RedisDoer
+}
+
+// NewRedisStore creates a RateLimitStore in Redis, with the commands EVAL and GET
+func NewRedisStore(client RedisDoer) RateLimitStore {
+	return redisStore{client}
+}
+
+// redisIncr increments a counter and sets its expiration on creation, atomically: a counter can't be left without
+// expiration
+const redisIncr = `local count = redis.call('INCR', KEYS[1])
+if count == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return count`
+
+func (s redisStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	reply, err := s.client.Do(ctx, "EVAL", redisIncr, 1, key, int64(ttl/time.Millisecond))
+	if err != nil {
+		return 0, err
+	}
+	return redisInt(reply)
+}
+
+func (s redisStore) Get(ctx context.Context, key string) (int64, error) {
+	reply, err := s.client.Do(ctx, "GET", key)
+	if err != nil || reply == nil {
+		// the clients return nil or an error for the missing keys
+		return 0, nil
+	}
+	return redisInt(reply)
+}
+
+// redisInt converts a Redis reply to an integer
+func redisInt(reply interface{}) (int64, error) {
+	switch v := reply.(type) {
+	case int64:
+		return v, nil
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	case []byte:
+		return strconv.ParseInt(string(v), 10, 64)
+	}
+	return 0, fmt.Errorf("unexpected redis reply %T", reply)
+}
+
+// MemcacheClient is the subset of a memcache client used by the memcache store, adapt the client of your choice
+// (gomemcache...) to it
+type MemcacheClient interface {
+	// Add stores value under key if it does not exist, an error is returned otherwise
+	Add(key string, value []byte, ttl time.Duration) error
+	// Increment adds delta to the value of key and returns it, an error is returned if key does not exist
+	Increment(key string, delta uint64) (uint64, error)
+	// Get returns the value of key, nil if it does not exist
+	Get(key string) ([]byte, error)
+}
+
+// memcacheStore is a RateLimitStore in memcache
+type memcacheStore struct {
+	client MemcacheClient
+}
+
+// NewMemcacheStore creates a RateLimitStore in memcache
+func NewMemcacheStore(client MemcacheClient) RateLimitStore {
+	return memcacheStore{client}
+}
+
+func (s memcacheStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	count, err := s.client.Increment(key, 1)
+	if err == nil {
+		return int64(count), nil
+	}
+	// the counter does not exist yet, or was created concurrently
+	if s.client.Add(key, []byte("1"), ttl) == nil {
+		return 1, nil
+	}
+	count, err = s.client.Increment(key, 1)
+	return int64(count), err
+}
+
+func (s memcacheStore) Get(ctx context.Context, key string) (int64, error) {
+	value, err := s.client.Get(key)
+	if err != nil || value == nil {
+		return 0, nil
+	}
+	return strconv.ParseInt(string(value), 10, 64)
+}
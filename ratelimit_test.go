@@ -0,0 +1,112 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	limiter := NewRateLimiter(2, time.Hour, nil)
+	router := New()
+	router.GET("/items", func(r *http.Request, p Params) (interface{}, error) {
+		return "ok", nil
+	}).Use(limiter.Middleware)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		code       int
+		remaining  string
+	}{
+		{"first", "1.2.3.4:1000", 200, "1"},
+		{"second", "1.2.3.4:1001", 200, "0"},
+		{"above the limit", "1.2.3.4:1002", 429, "0"},
+		{"other client", "5.6.7.8:1000", 200, "1"},
+		{"unknown client", "@", 500, ""},
+		{"unknown client again", "", 500, ""},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest("GET", "/items", nil)
+		req.RemoteAddr = test.remoteAddr
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != test.code {
+			t.Errorf("%s: got %d, expected %d", test.name, w.Code, test.code)
+		}
+		if got := w.Header().Get("X-RateLimit-Remaining"); got != test.remaining {
+			t.Errorf("%s: got %q remaining, expected %q", test.name, got, test.remaining)
+		}
+		if test.code == 429 && w.Header().Get("Retry-After") == "" {
+			t.Errorf("%s: no Retry-After", test.name)
+		}
+	}
+}
+
+func TestRateLimiterInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		limit  int
+		window time.Duration
+	}{
+		{"zero window", 10, 0},
+		{"negative window", 10, -time.Second},
+		{"zero limit", 0, time.Second},
+	}
+	for _, test := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: no panic", test.name)
+				}
+			}()
+			NewRateLimiter(test.limit, test.window, nil)
+		}()
+	}
+
+	limiter := &RateLimiter{Store: NewMemoryStore()}
+	if allowed, _, _, err := limiter.Allow(context.Background(), "key"); allowed == false || err == nil {
+		t.Error("a limiter without window did not fail open with an error")
+	}
+}
+
+// fakeRedis records the commands and runs the increment script in memory
+type fakeRedis struct {
+	commands []string
+	values   map[string]int64
+}
+
+func (f *fakeRedis) Do(ctx context.Context, args ...interface{}) (interface{}, error) {
+	f.commands = append(f.commands, args[0].(string))
+	switch args[0] {
+	case "EVAL":
+		key := args[3].(string)
+		f.values[key]++
+		return f.values[key], nil
+	case "GET":
+		if value, ok := f.values[args[1].(string)]; ok == true {
+			return []byte(strconv.FormatInt(value, 10)), nil
+		}
+		return nil, nil
+	}
+	return nil, nil
+}
+
+func TestRedisStore(t *testing.T) {
+	redis := &fakeRedis{values: make(map[string]int64)}
+	limiter := NewRateLimiter(1, time.Hour, NewRedisStore(redis))
+	for i, expected := range []bool{true, false} {
+		allowed, _, _, err := limiter.Allow(context.Background(), "client")
+		if err != nil || allowed != expected {
+			t.Errorf("request %d: got %t (%v), expected %t", i, allowed, err, expected)
+		}
+	}
+	for _, command := range redis.commands {
+		if command != "EVAL" && command != "GET" {
+			t.Errorf("non-atomic command %s", command)
+		}
+	}
+}
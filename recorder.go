@@ -0,0 +1,203 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Exchange is a request/response pair captured by a Recorder
+type Exchange struct {
+	Time           time.Time
+	Method         string
+	URL            string
+	ClientIP       string
+	RequestHeader  http.Header
+	RequestBody    string
+	Status         int
+	ResponseHeader http.Header
+	ResponseBody   string
+	Latency        time.Duration
+}
+
+// Recorder captures the traffic of a router, for debugging purpose. The exchanges are kept in a ring buffer and can
+// also be written to a file. Enable it with Router.Record.
+type Recorder struct {
+	// MaxBody is the number of bytes of the bodies kept
+	MaxBody int
+	// Redact lists the headers whose values are masked
+	Redact []string
+	// Writer receives the exchanges as JSON lines if not nil
+	Writer io.Writer
+
+	mu   sync.Mutex
+	ring []Exchange
+	next int
+	full bool
+}
+
+// NewRecorder creates a Recorder keeping the last size exchanges in memory
+func NewRecorder(size int) *Recorder {
+	return &Recorder{
+		MaxBody: 4096,
+		Redact:  []string{"Authorization", "Cookie", "Set-Cookie", "Proxy-Authorization"},
+		ring:    make([]Exchange, size),
+	}
+}
+
+// Record enables the recording of the traffic, nil disables it
+func (r *Router) Record(rec *Recorder) {
+	r.mu.Lock()
+	r.recorder = rec
+	r.mu.Unlock()
+}
+
+// Save stores an exchange
+func (rec *Recorder) Save(ex Exchange) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if len(rec.ring) != 0 {
+		rec.ring[rec.next] = ex
+		rec.next = (rec.next + 1) % len(rec.ring)
+		if rec.next == 0 {
+			rec.full = true
+		}
+	}
+	if rec.Writer != nil {
+		chunk, err := json.Marshal(ex)
+		if err == nil {
+			_, err = rec.Writer.Write(append(chunk, '\n'))
+		}
+		if err != nil {
+			log.Println("error while recording exchange:", err)
+		}
+	}
+}
+
+// Exchanges returns the exchanges kept in memory, the most recent first
+func (rec *Recorder) Exchanges() []Exchange {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	count := rec.next
+	if rec.full == true {
+		count = len(rec.ring)
+	}
+	exchanges := make([]Exchange, 0, count)
+	for i := 1; i <= count; i++ {
+		exchanges = append(exchanges, rec.ring[(rec.next-i+len(rec.ring))%len(rec.ring)])
+	}
+	return exchanges
+}
+
+// Browse is a Controller listing the recent exchanges, the limit query parameter restricts their number.
+// Protect it like any admin route:
+//
+//	admin.GET("/traffic", recorder.Browse)
+func (rec *Recorder) Browse(r *http.Request, p Params) (interface{}, error) {
+	exchanges := rec.Exchanges()
+	if limit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && limit >= 0 && limit < len(exchanges) {
+		exchanges = exchanges[:limit]
+	}
+	return exchanges, nil
+}
+
+// sanitize returns a copy of header with the Redact headers and the headers of the redactor of the router masked
+func (rec *Recorder) sanitize(req *http.Request, header http.Header) http.Header {
+	header = routerFromRequest(req).getRedactor().Header(header)
+	for _, name := range rec.Redact {
+		if _, ok := header[http.CanonicalHeaderKey(name)]; ok == true {
+			header.Set(name, "[REDACTED]")
+		}
+	}
+	return header
+}
+
+func cloneHeader(header http.Header) http.Header {
+	clone := make(http.Header, len(header))
+	for name, values := range header {
+		clone[name] = append([]string(nil), values...)
+	}
+	return clone
+}
+
+// capture wraps the request and the writer, the returned function saves the exchange once the response is written
+func (rec *Recorder) capture(w http.ResponseWriter, req *http.Request) (http.ResponseWriter, *http.Request, func()) {
+	ex := Exchange{
+		Time:          time.Now(),
+		Method:        req.Method,
+		URL:           req.URL.String(),
+		RequestHeader: rec.sanitize(req, req.Header),
+	}
+	if ip := ClientIP(req); ip != nil {
+		ex.ClientIP = ip.String()
+	}
+	body := &cappedBuffer{max: rec.MaxBody}
+	if req.Body != nil {
+		req.Body = readCloser{io.TeeReader(req.Body, body), req.Body}
+	}
+	rw := &recordingResponseWriter{ResponseWriter: w, body: cappedBuffer{max: rec.MaxBody}}
+	return rw, req, func() {
+		ex.Latency = time.Since(ex.Time)
+		ex.RequestBody = routerFromRequest(req).getRedactor().Body(body.String())
+		ex.Status = rw.status
+		if ex.Status == 0 {
+			ex.Status = 200
+		}
+		ex.ResponseHeader = rec.sanitize(req, w.Header())
+		ex.ResponseBody = routerFromRequest(req).getRedactor().Body(rw.body.String())
+		rec.Save(ex)
+	}
+}
+
+// cappedBuffer keeps the first max bytes written to it
+type cappedBuffer struct {
+	bytes.Buffer
+	max int
+}
+
+func (b *cappedBuffer) Write(data []byte) (int, error) {
+	if room := b.max - b.Len(); room > 0 {
+		if len(data) > room {
+			b.Buffer.Write(data[:room])
+		} else {
+			b.Buffer.Write(data)
+		}
+	}
+	return len(data), nil
+}
+
+type recordingResponseWriter struct {
+	http.ResponseWriter
+	status int
+	body   cappedBuffer
+}
+
+func (w *recordingResponseWriter) WriteHeader(code int) {
+	// the informational responses (103 Early Hints...) precede the final one, 101 ends the exchange
+	informational := code >= 100 && code < 200 && code != http.StatusSwitchingProtocols
+	if w.status == 0 && informational == false {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *recordingResponseWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = 200
+	}
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+// Flush implements http.Flusher, so that streamed responses keep being flushed
+func (w *recordingResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok == true {
+		flusher.Flush()
+	}
+}
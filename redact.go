@@ -0,0 +1,89 @@
+package rest
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// redacted replaces the masked values
+const redacted = "[REDACTED]"
+
+// Redactor masks the secrets (credentials, tokens...) in the recorded exchanges, the audit payloads, the error logs,
+// the error reports and the error details of the development mode
+type Redactor struct {
+	headers map[string]bool
+	fields  *regexp.Regexp
+	form    *regexp.Regexp
+}
+
+// NewRedactor creates a Redactor masking the given headers, and the given fields of the JSON and form encoded
+// bodies, the names being case insensitive
+func NewRedactor(headers []string, fields []string) *Redactor {
+	rd := &Redactor{headers: make(map[string]bool)}
+	for _, name := range headers {
+		rd.headers[http.CanonicalHeaderKey(name)] = true
+	}
+	if len(fields) != 0 {
+		quoted := make([]string, len(fields))
+		for i, field := range fields {
+			quoted[i] = regexp.QuoteMeta(field)
+		}
+		names := strings.Join(quoted, "|")
+		// the bodies may be truncated, they are not decoded
+		rd.fields = regexp.MustCompile(`(?i)("(?:` + names + `)"\s*:\s*)("(?:[^"\\]|\\.)*"?|[^,}\]\s]+)`)
+		// the form fields are also looked for in the URLs and the texts of the error messages
+		rd.form = regexp.MustCompile(`(?i)((?:^|[&?\s])(?:` + names + `)=)[^&\s]*`)
+	}
+	return rd
+}
+
+// DefaultRedactor returns a Redactor masking the authentication headers and the password, token and secret fields
+func DefaultRedactor() *Redactor {
+	return NewRedactor(
+		[]string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"},
+		[]string{"password", "token", "access_token", "refresh_token", "secret", "client_secret"},
+	)
+}
+
+// SetRedactor sets the redactor of the router, nil disables the redaction (the Redact headers of the Recorder are
+// still masked)
+func (r *Router) SetRedactor(rd *Redactor) {
+	r.mu.Lock()
+	r.redactor = rd
+	r.mu.Unlock()
+}
+
+// getRedactor returns the redactor of the router, nil if it has none or if r is nil
+func (r *Router) getRedactor() *Redactor {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.redactor
+}
+
+// Header returns a copy of header with the secret values masked
+func (rd *Redactor) Header(header http.Header) http.Header {
+	header = cloneHeader(header)
+	if rd == nil {
+		return header
+	}
+	for name := range header {
+		if rd.headers[name] == true {
+			header[name] = []string{redacted}
+		}
+	}
+	return header
+}
+
+// Body returns body with the values of the secret fields masked, body being JSON, form encoded or a text holding
+// name=value pairs
+func (rd *Redactor) Body(body string) string {
+	if rd == nil || rd.fields == nil {
+		return body
+	}
+	body = rd.fields.ReplaceAllString(body, `${1}"`+redacted+`"`)
+	return rd.form.ReplaceAllString(body, "${1}"+redacted)
+}
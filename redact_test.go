@@ -0,0 +1,56 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// recordLogger keeps the logs
+type recordLogger struct {
+	logs []string
+}
+
+func (l *recordLogger) Logf(level Level, format string, v ...interface{}) {
+	l.logs = append(l.logs, fmt.Sprintf(format, v...))
+}
+
+func TestRedactErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"error", errors.New(`bad request {"password": "hunter2"}`)},
+		{"parent", errorTransparent{NewError500(), errors.New("query failed: token=hunter2")}},
+		{"stack", errorTransparent{NewError500(), stackError{`{"secret": "hunter2"}`}}},
+	}
+	for _, test := range tests {
+		logger := new(recordLogger)
+		var reported *http.Request
+		r := New()
+		r.SetLogger(logger)
+		r.SetErrorLogging(ErrorLogging{ServerLevel: LevelError, Stack: true})
+		r.SetRedactor(DefaultRedactor())
+		r.SetErrorReporter(ErrorReporterFunc(func(req *http.Request, report ErrorReport) {
+			reported = req
+		}), nil)
+		r.GET("/", func(*http.Request, Params) (interface{}, error) {
+			return nil, test.err
+		})
+
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", "Bearer hunter2")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+		if logs := strings.Join(logger.logs, ""); len(logs) == 0 || strings.Contains(logs, "hunter2") == true {
+			t.Errorf("%s: logged %q", test.name, logs)
+		}
+		if reported == nil || reported.Header.Get("Authorization") != redacted {
+			t.Errorf("%s: reported %v", test.name, reported)
+		} else if req.Header.Get("Authorization") == redacted {
+			t.Errorf("%s: the header of the request was modified", test.name)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package rest
+
+import (
+	"net/http"
+	"runtime/debug"
+)
+
+// ErrorReport describes a server error or a panic of a controller
+type ErrorReport struct {
+	// Err is the error returned by the controller, nil for a panic
+	Err error
+	// Panic is the value recovered from the panic, nil for an error
+	Panic interface{}
+	// Stack is the stack trace of the panic, nil for an error
+	Stack  []byte
+	Status int
+
+	Method    string
+	Route     string // path of the route as registered, /users/:id for instance
+	Path      string
+	RequestID string
+	User      string
+	ClientIP  string // empty if unknown (Unix sockets...)
+}
+
+// ErrorReporter sends the server errors and the panics to an error tracker (Sentry, Rollbar...). Report is called
+// by the handler of the request, it should not block. The headers of the request are masked by the redactor of the
+// router, if any.
+type ErrorReporter interface {
+	Report(r *http.Request, report ErrorReport)
+}
+
+// ErrorReporterFunc adapts a function to an ErrorReporter
+type ErrorReporterFunc func(r *http.Request, report ErrorReport)
+
+// Report calls f
+func (f ErrorReporterFunc) Report(r *http.Request, report ErrorReport) {
+	f(r, report)
+}
+
+type errorReporting struct {
+	reporter ErrorReporter
+	user     func(r *http.Request) string
+}
+
+// SetErrorReporter sets the reporter of the 5xx errors and of the panics of the controllers, nil disables it.
+// user returns the user doing the request, from the authentication set by the middlewares. It may be nil.
+func (r *Router) SetErrorReporter(reporter ErrorReporter, user func(r *http.Request) string) {
+	r.mu.Lock()
+	if reporter == nil {
+		r.errorReporting = nil
+	} else {
+		r.errorReporting = &errorReporting{reporter, user}
+	}
+	r.mu.Unlock()
+}
+
+// RequestID returns the identifier of the request, as set by the client or a proxy in the X-Request-Id header
+func RequestID(r *http.Request) string {
+	return r.Header.Get("X-Request-Id")
+}
+
+// getErrorReporting returns the error reporting settings of the router, nil if it has none
+func (r *Router) getErrorReporting() *errorReporting {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.errorReporting
+}
+
+// report fills the request metadata of the report and sends it to the reporter of the router, if any
+func (r *Router) report(req *http.Request, report ErrorReport) {
+	reporting := r.getErrorReporting()
+	if reporting == nil {
+		return
+	}
+	if route := stateFromRequest(req).route; route != nil {
+		report.Route = route.path
+	}
+	report.Method = req.Method
+	report.Path = req.URL.Path
+	report.RequestID = RequestID(req)
+	if reporting.user != nil {
+		report.User = reporting.user(req)
+	}
+	if ip := ClientIP(req); ip != nil {
+		report.ClientIP = ip.String()
+	}
+	if rd := r.getRedactor(); rd != nil {
+		// the reporters send the headers of the request to the error tracker
+		req = req.Clone(req.Context())
+		req.Header = rd.Header(req.Header)
+	}
+	reporting.reporter.Report(req, report)
+}
+
+// reportPanics reports the panic of the controller, if any, before propagating it.
+// http.ErrAbortHandler is used to abort responses on purpose, it is not reported.
+func reportPanics(r *http.Request) {
+	if rcv := recover(); rcv != nil {
+		if rcv != http.ErrAbortHandler {
+			routerFromRequest(r).report(r, ErrorReport{
+				Panic:  rcv,
+				Stack:  debug.Stack(),
+				Status: 500,
+			})
+		}
+		panic(rcv)
+	}
+}
@@ -0,0 +1,89 @@
+package rest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// requestLogger prefixes the logs with the context of the request
+type requestLogger struct {
+	parent Logger
+	prefix string
+}
+
+func (l requestLogger) Logf(level Level, format string, v ...interface{}) {
+	// the prefix is sent by the client, it must not be read as a format
+	l.parent.Logf(level, "%s"+format, append([]interface{}{l.prefix}, v...)...)
+}
+
+// RequestLogger derives a logger from the logger of the router for each request, its logs are prefixed with the
+// request ID, the route, the client IP (see ClientIP) and the user (user may be nil). The controllers get it with
+// Log, and the errors of the routes are logged with it.
+// The request ID is read from the X-Request-Id header, or generated if the client did not send a valid one (see
+// validRequestID). It is sent back in the X-Request-Id header of the response.
+func RequestLogger(user func(r *http.Request) string) Middleware {
+	return func(next Controller) Controller {
+		return func(r *http.Request, p Params) (interface{}, error) {
+			state := stateFromRequest(r)
+			id := RequestID(r)
+			if validRequestID(id) == false {
+				id = newRequestID()
+				r.Header.Set("X-Request-Id", id)
+			}
+			if state.w != nil {
+				state.w.Header().Set("X-Request-Id", id)
+			}
+
+			fields := []string{"request_id=" + id}
+			if state.route != nil {
+				fields = append(fields, "method="+state.route.method, "route="+state.route.path)
+			}
+			if ip := ClientIP(r); ip != nil {
+				fields = append(fields, "client_ip="+ip.String())
+			}
+			if user != nil {
+				if u := user(r); u != "" {
+					fields = append(fields, "user="+u)
+				}
+			}
+			state.logger = requestLogger{
+				parent: routerFromRequest(r).getLogger(),
+				prefix: strings.Join(fields, " ") + " ",
+			}
+			return next(r, p)
+		}
+	}
+}
+
+// Log returns the logger of the request, the logger of the router if RequestLogger is not used
+func Log(r *http.Request) Logger {
+	if logger := stateFromRequest(r).logger; logger != nil {
+		return logger
+	}
+	return routerFromRequest(r).getLogger()
+}
+
+// validRequestID tells whether the request ID sent by a client can be logged: up to 128 printable ASCII characters,
+// without spaces, so that it can't forge log entries
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns a random request ID
+func newRequestID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,44 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestLoggerID(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+		kept bool
+	}{
+		{"valid", "4f2a-91c0", true},
+		{"verbs", "%s%d%v", true},
+		{"line feed", "abc\nforged entry", false},
+		{"carriage return", "abc\rforged", false},
+		{"space", "a b", false},
+		{"too long", strings.Repeat("a", 129), false},
+	}
+	for _, test := range tests {
+		logger := new(recordLogger)
+		r := New()
+		r.SetLogger(logger)
+		r.GET("/", func(req *http.Request, p Params) (interface{}, error) {
+			Log(req).Logf(LevelInfo, "value %d\n", 42)
+			return nil, nil
+		}).Use(RequestLogger(nil))
+
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header["X-Request-Id"] = []string{test.id}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if len(logger.logs) != 1 || strings.HasSuffix(logger.logs[0], " value 42\n") == false ||
+			strings.Count(logger.logs[0], "\n") != 1 || strings.Contains(logger.logs[0], "\r") == true {
+			t.Errorf("%s: logged %q", test.name, logger.logs)
+		}
+		if kept := w.Header().Get("X-Request-Id") == test.id; kept != test.kept {
+			t.Errorf("%s: got the request ID %q", test.name, w.Header().Get("X-Request-Id"))
+		}
+	}
+}
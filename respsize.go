@@ -0,0 +1,215 @@
+package rest
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+)
+
+// ResponseSizePolicy is how the responses larger than the MaxResponseSize of their route are handled
+type ResponseSizePolicy int
+
+const (
+	// ResponseSizeError replies a 500 instead of the response
+	ResponseSizeError ResponseSizePolicy = iota
+	// ResponseSizeTruncate sends the first items of the lists which fit, with a Warning header telling how many
+	// were sent. The other responses get a 500.
+	ResponseSizeTruncate
+	// ResponseSizeStream sends the lists without encoding them in memory beyond the limit, so without Content-Length
+	// nor digest. The other responses are sent as is.
+	ResponseSizeStream
+)
+
+func (p ResponseSizePolicy) String() string {
+	switch p {
+	case ResponseSizeError:
+		return "error"
+	case ResponseSizeTruncate:
+		return "truncate"
+	case ResponseSizeStream:
+		return "stream"
+	}
+	return "unknown"
+}
+
+// responseLimit is the maximum response size of the route of a request
+type responseLimit struct {
+	max    int64
+	policy ResponseSizePolicy
+}
+
+// WithMaxResponseSize sets the maximum size of the encoded responses in bytes, and what happens to the larger ones.
+// The JSON and XML lists are encoded item by item, so the limit applies before the whole list is encoded.
+func (rt *Route) WithMaxResponseSize(size int64, policy ResponseSizePolicy) *Route {
+	return rt.update(func(options *RouteOptions) {
+		options.MaxResponseSize = size
+		options.ResponseSizePolicy = policy
+	})
+}
+
+// isList tells if data is encoded as a list of items, one at a time. The types with their own encoding are not.
+func isList(data interface{}) bool {
+	switch data.(type) {
+	case json.Marshaler, xml.Marshaler, encoding.TextMarshaler:
+		return false
+	}
+	val := reflect.ValueOf(data)
+	if val.Kind() == reflect.Slice && val.IsNil() == true {
+		return false
+	}
+	return (val.Kind() == reflect.Slice || val.Kind() == reflect.Array) && val.Type().Elem().Kind() != reflect.Uint8
+}
+
+// listEncoder encodes the lists in JSON or XML one item at a time, as json.Marshal (or MarshalIndent) and
+// marshalXML do
+type listEncoder struct {
+	format int
+	indent bool
+	xml    XMLOptions
+}
+
+func (e listEncoder) open() []byte {
+	if e.format == formatJSON {
+		return []byte("[")
+	}
+	if e.xml.RootName != "" {
+		return []byte("<" + e.xml.RootName + ">")
+	}
+	return nil
+}
+
+func (e listEncoder) close(items int) []byte {
+	if e.format == formatJSON {
+		if e.indent == true && items != 0 {
+			return []byte("\n]")
+		}
+		return []byte("]")
+	}
+	if e.xml.RootName != "" {
+		return []byte("</" + e.xml.RootName + ">")
+	}
+	return nil
+}
+
+func (e listEncoder) item(v interface{}, i int) ([]byte, error) {
+	if e.format == formatJSON {
+		var sep string
+		var chunk []byte
+		var err error
+		if e.indent == true {
+			sep = "\n  "
+			chunk, err = json.MarshalIndent(v, "  ", "  ")
+		} else {
+			chunk, err = json.Marshal(v)
+		}
+		if i != 0 {
+			sep = "," + sep
+		}
+		return append([]byte(sep), chunk...), err
+	}
+	if e.xml.RootName == "" {
+		return xml.Marshal(v)
+	}
+	var buf bytes.Buffer
+	name := e.xml.ItemName
+	if name == "" {
+		name = "item"
+	}
+	err := xml.NewEncoder(&buf).EncodeElement(v, xml.StartElement{Name: xml.Name{Local: name}})
+	return buf.Bytes(), err
+}
+
+// outputList encodes the list data item by item, applying the limit of the route
+func outputList(w http.ResponseWriter, r *http.Request, code int, data interface{}, format int, limit responseLimit) error {
+	var buf bytes.Buffer
+
+	router := routerFromRequest(r)
+	e := listEncoder{format, format == formatJSON && router.isDev() == true, router.getXMLOptions()}
+	val := reflect.ValueOf(data)
+	n := val.Len()
+	buf.Write(e.open())
+	for i := 0; i < n; i++ {
+		item, err := e.item(val.Index(i).Interface(), i)
+		if err != nil {
+			return err
+		}
+		if int64(buf.Len()+len(item)+len(e.close(i+1))) <= limit.max {
+			buf.Write(item)
+			continue
+		}
+
+		oversized(r, limit)
+		switch limit.policy {
+		case ResponseSizeTruncate:
+			w.Header().Set("Warning", fmt.Sprintf(`199 - "response truncated to %d of %d items"`, i, n))
+			buf.Write(e.close(i))
+			return writeChunk(w, r, code, buf.Bytes())
+		case ResponseSizeStream:
+			return streamList(w, r, code, e, val, i, append(buf.Bytes(), item...))
+		}
+		return outputTooLarge(w, r, format)
+	}
+	buf.Write(e.close(n))
+	return writeChunk(w, r, code, buf.Bytes())
+}
+
+// streamList sends the beginning of the list already encoded, then encodes and sends its items from the one
+// after from
+func streamList(w http.ResponseWriter, r *http.Request, code int, e listEncoder, val reflect.Value, from int, head []byte) error {
+	s := &streamWriter{
+		w:    w,
+		done: r.Context().Done(),
+	}
+	w.WriteHeader(code)
+	if _, err := s.Write(head); err != nil {
+		return err
+	}
+	for i := from + 1; i < val.Len(); i++ {
+		item, err := e.item(val.Index(i).Interface(), i)
+		if err != nil {
+			return err
+		}
+		if _, err = s.Write(item); err != nil {
+			return err
+		}
+	}
+	_, err := s.Write(e.close(val.Len()))
+	return err
+}
+
+// writeChunk sends the encoded response chunk
+func writeChunk(w http.ResponseWriter, r *http.Request, code int, chunk []byte) error {
+	if stateFromRequest(r).digest == true {
+		setDigest(w.Header(), chunk)
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(chunk)))
+	w.WriteHeader(code)
+	_, err := w.Write(chunk)
+	return err
+}
+
+// outputTooLarge replies a 500 instead of a response exceeding the limit of its route
+func outputTooLarge(w http.ResponseWriter, r *http.Request, format int) error {
+	w.Header().Del("Warning")
+	stateFromRequest(r).responseLimit = responseLimit{}
+	return output(w, r, 500, NewError500(), format)
+}
+
+// oversized logs and counts a response exceeding the limit of its route
+func oversized(r *http.Request, limit responseLimit) {
+	level := LevelWarn
+	if limit.policy == ResponseSizeError {
+		level = LevelError
+	}
+	Log(r).Logf(level, "response larger than %d bytes, policy %s\n", limit.max, limit.policy)
+	if router := routerFromRequest(r); router != nil {
+		tags := stateFromRequest(r).tags()
+		tags["policy"] = limit.policy.String()
+		router.incr("rest.responses.oversized", tags)
+	}
+}
@@ -0,0 +1,102 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type sizeItem struct {
+	ID   int
+	Name string
+}
+
+type sizePage []int
+
+func (p sizePage) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string][]int{"items": p})
+}
+
+func TestMaxResponseSize(t *testing.T) {
+	items := make([]sizeItem, 50)
+	for i := range items {
+		items[i] = sizeItem{i, "item"}
+	}
+	full, _ := json.Marshal(items)
+	list := func(r *http.Request, p Params) (interface{}, error) {
+		return items, nil
+	}
+
+	router := New()
+	router.GET("/error", list).WithMaxResponseSize(300, ResponseSizeError)
+	router.GET("/truncate", list).WithMaxResponseSize(300, ResponseSizeTruncate)
+	router.GET("/stream", list).WithMaxResponseSize(300, ResponseSizeStream)
+	router.GET("/fits", list).WithMaxResponseSize(1<<20, ResponseSizeError)
+	router.GET("/object", func(r *http.Request, p Params) (interface{}, error) {
+		return map[string]interface{}{"items": items}, nil
+	}).WithMaxResponseSize(300, ResponseSizeTruncate)
+	router.GET("/marshaler", func(r *http.Request, p Params) (interface{}, error) {
+		return sizePage{1, 2, 3}, nil
+	}).WithMaxResponseSize(300, ResponseSizeError)
+
+	tests := []struct {
+		path    string
+		accept  string
+		code    int
+		warning string
+		body    string
+	}{
+		{"/error", "application/json", 500, "", ""},
+		{"/error", "application/xml", 500, "", ""},
+		{"/truncate", "application/json", 200, `199 - "response truncated to 12 of 50 items"`, ""},
+		{"/truncate", "application/xml", 200, `199 - "response truncated to 6 of 50 items"`, ""},
+		{"/stream", "application/json", 200, "", string(full)},
+		{"/fits", "application/json", 200, "", string(full)},
+		{"/object", "application/json", 500, "", ""},
+		{"/marshaler", "application/json", 200, "", `{"items":[1,2,3]}`},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest("GET", test.path, nil)
+		req.Header.Set("Accept", test.accept)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != test.code {
+			t.Errorf("%s %s: got %d, expected %d", test.path, test.accept, w.Code, test.code)
+		}
+		if w.Header().Get("Warning") != test.warning {
+			t.Errorf("%s %s: got warning %q, expected %q", test.path, test.accept, w.Header().Get("Warning"), test.warning)
+		}
+		if test.body != "" && w.Body.String() != test.body {
+			t.Errorf("%s %s: got %s, expected %s", test.path, test.accept, w.Body.String(), test.body)
+		}
+		if test.path == "/truncate" && w.Body.Len() > 300 {
+			t.Errorf("%s %s: %d bytes sent", test.path, test.accept, w.Body.Len())
+		}
+		if test.path == "/truncate" && test.accept == "application/json" {
+			var truncated []sizeItem
+			if err := json.Unmarshal(w.Body.Bytes(), &truncated); err != nil || len(truncated) != 12 {
+				t.Errorf("invalid truncated list: %s", w.Body.String())
+			}
+		}
+	}
+}
+
+func TestMaxResponseSizeIndent(t *testing.T) {
+	items := []sizeItem{{1, "a"}, {2, strings.Repeat("b", 100)}, {3, "c"}}
+	router := New()
+	router.SetDev(true)
+	router.GET("/stream", func(r *http.Request, p Params) (interface{}, error) {
+		return items, nil
+	}).WithMaxResponseSize(50, ResponseSizeStream)
+
+	req := httptest.NewRequest("GET", "/stream", nil)
+	req.Header.Set("Accept", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	expected, _ := json.MarshalIndent(items, "", "  ")
+	if w.Body.String() != string(expected) {
+		t.Errorf("got %s, expected %s", w.Body.String(), expected)
+	}
+}
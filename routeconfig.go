@@ -0,0 +1,115 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+)
+
+// RouteConfig declares a route in a configuration file
+type RouteConfig struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	// Controller is the name of the controller serving the route
+	Controller string `json:"controller,omitempty"`
+	// Upstream is the URL of a server the requests are proxied to, instead of a controller
+	Upstream string `json:"upstream,omitempty"`
+	// Middlewares are the names of the middlewares (authentication, rate limits...) applied to the route, in order
+	Middlewares []string `json:"middlewares,omitempty"`
+}
+
+// RoutesConfig is the content of a routes configuration file:
+//
+//	{
+//		"routes": [
+//			{"method": "GET", "path": "/users/:id", "controller": "getUser", "middlewares": ["auth"]},
+//			{"method": "GET", "path": "/legacy/*path", "upstream": "http://legacy.local:8080"}
+//		]
+//	}
+type RoutesConfig struct {
+	Routes []RouteConfig `json:"routes"`
+}
+
+// Bindings binds the names used in the configuration files to the controllers and middlewares
+type Bindings struct {
+	Controllers map[string]Controller
+	Middlewares map[string]Middleware
+}
+
+// LoadRoutes registers the routes declared in a JSON configuration (see RoutesConfig), the names being resolved with
+// bindings. Nothing is registered if the configuration is invalid, declares a route twice or a route already
+// registered.
+func (r *Router) LoadRoutes(reader io.Reader, bindings Bindings) (err error) {
+	var config RoutesConfig
+
+	err = json.NewDecoder(reader).Decode(&config)
+	if err != nil {
+		return fmt.Errorf("failed to read routes configuration: %s", err)
+	}
+	ctrls := make([]Controller, len(config.Routes))
+	declared := make(map[string]bool)
+	for i, route := range config.Routes {
+		ctrls[i], err = bindings.controller(route)
+		if err != nil {
+			return fmt.Errorf("route %s %s: %s", route.Method, route.Path, err)
+		}
+		key := strings.ToUpper(route.Method) + " " + route.Path
+		r.mu.RLock()
+		_, registered := r.routes[key]
+		r.mu.RUnlock()
+		if declared[key] == true || registered == true {
+			return fmt.Errorf("route %s %s: already registered", route.Method, route.Path)
+		}
+		declared[key] = true
+	}
+
+	// httprouter panics on the paths conflicting with the registered ones (:id and :name in the same segment...),
+	// the routes of the configuration are then removed
+	var loaded []RouteConfig
+	defer func() {
+		if rcv := recover(); rcv != nil {
+			for _, route := range loaded {
+				r.Remove(strings.ToUpper(route.Method), route.Path)
+			}
+			err = fmt.Errorf("invalid routes configuration: %v", rcv)
+		}
+	}()
+	for i, route := range config.Routes {
+		r.handleController(strings.ToUpper(route.Method), route.Path, ctrls[i])
+		loaded = append(loaded, route)
+	}
+	return nil
+}
+
+func (b Bindings) controller(route RouteConfig) (Controller, error) {
+	var ctrl Controller
+
+	if route.Controller != "" && route.Upstream != "" {
+		return nil, fmt.Errorf("controller and upstream are exclusive")
+	} else if route.Controller != "" {
+		var ok bool
+		ctrl, ok = b.Controllers[route.Controller]
+		if ok == false {
+			return nil, fmt.Errorf("unknown controller %q", route.Controller)
+		}
+	} else if route.Upstream != "" {
+		upstream, err := url.Parse(route.Upstream)
+		if err != nil {
+			return nil, fmt.Errorf("invalid upstream: %s", err)
+		}
+		ctrl = HandlerController(httputil.NewSingleHostReverseProxy(upstream))
+	} else {
+		return nil, fmt.Errorf("missing controller or upstream")
+	}
+	for i := len(route.Middlewares) - 1; i >= 0; i-- {
+		middleware, ok := b.Middlewares[route.Middlewares[i]]
+		if ok == false {
+			return nil, fmt.Errorf("unknown middleware %q", route.Middlewares[i])
+		}
+		ctrl = middleware(ctrl)
+	}
+	return ctrl, nil
+}
@@ -0,0 +1,38 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLoadRoutesConflicts(t *testing.T) {
+	ok := func(*http.Request, Params) (interface{}, error) {
+		return "ok", nil
+	}
+	tests := []struct {
+		name   string
+		config string
+		loaded bool
+	}{
+		{"valid", `{"routes": [{"method": "GET", "path": "/a", "controller": "ok"}, {"method": "post", "path": "/a", "controller": "ok"}]}`, true},
+		{"duplicate", `{"routes": [{"method": "GET", "path": "/a", "controller": "ok"}, {"method": "get", "path": "/a", "controller": "ok"}]}`, false},
+		{"registered", `{"routes": [{"method": "GET", "path": "/a", "controller": "ok"}, {"method": "GET", "path": "/users", "controller": "ok"}]}`, false},
+		{"unknown controller", `{"routes": [{"method": "GET", "path": "/a", "controller": "ok"}, {"method": "GET", "path": "/b", "controller": "ko"}]}`, false},
+	}
+	for _, test := range tests {
+		r := New()
+		r.GET("/users", ok)
+		r.GET("/users/:id", ok)
+		err := r.LoadRoutes(strings.NewReader(test.config), Bindings{Controllers: map[string]Controller{"ok": ok}})
+		if (err == nil) != test.loaded {
+			t.Errorf("%s: got %v", test.name, err)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/a", nil))
+		if (w.Code == 200) != test.loaded {
+			t.Errorf("%s: GET /a replied %d", test.name, w.Code)
+		}
+	}
+}
@@ -1,14 +1,18 @@
 package rest
 
 import (
+	"context"
 	"encoding/json"
-	"encoding/xml"
 	"errors"
-	"io/ioutil"
 	"log"
+	"net"
 	"net/http"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
 )
@@ -17,11 +21,44 @@ const (
 	formatJSON = iota
 	formatXML
 	formatFORM
+	formatJSONAPI
+	formatHAL
+	formatCSV
 )
 
 // Router ...
 type Router struct {
 	*httprouter.Router
+
+	mu             sync.RWMutex
+	trustedProxies []*net.IPNet
+	maintenance    *Maintenance
+	routes         map[string]*Route
+	dispatchers    map[string]bool
+	modules        []Module
+	recorder       *Recorder
+	openapi        *OpenAPI
+
+	responseValidation responseValidation
+	templates          *Templates
+	timeLayout         string
+	xmlOptions         *XMLOptions
+	metrics            Metrics
+	defaultHeaders     http.Header
+	errorMappings      []errorMapping
+	logger             Logger
+	errorLogging       *errorLogger
+	errorReporting     *errorReporting
+	shedder            *LoadShedder
+	onStart            []Hook
+	onStop             []stopHook
+	shutdownTimeout    time.Duration
+	config             Config
+	cors               *cors
+	redactor           *Redactor
+	purger             Purger
+	eventBus           EventBus
+	flags              FlagProvider
 }
 
 // Params contain an httprouter.Param, in order to avoid useless import of httprouter
@@ -93,33 +130,100 @@ type RespCType interface {
 // An error of type Error can be returned in order to overwrite the default error message.
 type Controller func(r *http.Request, p Params) (interface{}, error)
 
-func parseForm(form map[string][]string, v interface{}) error {
+func parseForm(form map[string][]string, v interface{}, c coercion) error {
+	var errs []FieldError
+
 	val := reflect.ValueOf(v)
 	t := val.Type()
 	if t.Kind() != reflect.Ptr || val.IsNil() {
 		return errors.New("Cannot parse form to non-pointer types")
 	}
 	val = val.Elem()
-	for k, v := range form {
+	if val.Kind() != reflect.Struct {
+		return errors.New("Cannot parse form to non-struct types")
+	}
+	// the fields are set in order, so that the errors are deterministic
+	keys := make([]string, 0, len(form))
+	for k := range form {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := form[k]
 		if len(v) == 0 {
 			continue
 		}
-		field := val.FieldByNameFunc(func(s string) bool {
-			key := strings.ToLower(k)
-			str := strings.ToLower(s)
-			return key == str
-		})
-		if field.Kind() == reflect.String {
+		field := formField(val, k)
+		if field.IsValid() == true && field.Type() == timeType {
+			t, err := parseTime(v[0], c.layout)
+			if err != nil {
+				errs = append(errs, FieldError{Field: k, Message: err.Error()})
+			} else {
+				field.Set(reflect.ValueOf(t))
+			}
+		} else if field.IsValid() == true && field.Type() == durationType {
+			d, err := parseDuration(v[0])
+			if err != nil {
+				errs = append(errs, FieldError{Field: k, Message: err.Error()})
+			} else {
+				field.SetInt(int64(d))
+			}
+		} else if field.Kind() == reflect.String {
 			field.SetString(v[0])
+		} else if c.lenient == true && field.IsValid() == true {
+			coerced, err := coerceString(v[0], field.Type())
+			if err != nil {
+				errs = append(errs, FieldError{Field: k, Message: err.Error()})
+			} else if _, ok := coerced.(string); ok == false {
+				field.Set(reflect.ValueOf(coerced).Convert(field.Type()))
+			}
 		}
 	}
+	if len(errs) != 0 {
+		return ValidationError{Message: "invalid parameters", Errors: errs}
+	}
 	return nil
 }
 
+// formField returns the settable field of the struct val matching name regardless of case, the nil embedded
+// structs on the way are allocated. It returns the zero Value if there is no such field.
+func formField(val reflect.Value, name string) reflect.Value {
+	key := strings.ToLower(name)
+	sf, ok := val.Type().FieldByNameFunc(func(s string) bool {
+		return strings.ToLower(s) == key
+	})
+	if ok == false || sf.PkgPath != "" {
+		return reflect.Value{}
+	}
+	for i, index := range sf.Index {
+		if i > 0 && val.Kind() == reflect.Ptr {
+			if val.IsNil() == true {
+				if val.CanSet() == false {
+					return reflect.Value{}
+				}
+				val.Set(reflect.New(val.Type().Elem()))
+			}
+			val = val.Elem()
+		}
+		val = val.Field(index)
+	}
+	if val.CanSet() == false {
+		return reflect.Value{}
+	}
+	return val
+}
+
 // Parse is an helper function to parse the body according to its content-type. It supports json, xml and www-form-urlencoded
+// gzip and deflate compressed bodies are transparently decompressed, up to MaxDecompressedSize bytes.
+// The fields with an enum tag (`enum:"name,date,size"`) are checked once parsed, the requests with other values are
+// rejected with a 400 listing the allowed ones. The documents and forms are bounded by DefaultParseLimits, the XML
+// documents by the XML options of the router too (see SetXMLOptions).
+// The time.Duration fields are Go durations ("1m30s") or numbers of seconds, quoted or not.
 func Parse(r *http.Request, v interface{}) error {
 	var err error
 
+	defer stateFromRequest(r).timing.addBind(time.Now())
+
 	outputFormat, _ := getFormat(r, "Accept")
 	inputFormat, found := getFormat(r, "Content-Type")
 	if found == false {
@@ -127,34 +231,30 @@ func Parse(r *http.Request, v interface{}) error {
 			// 	return Error500{"unsupported Content-Type: " + header[0]}
 		}
 		inputFormat = outputFormat
-	}
-
-	if inputFormat == formatJSON {
-		chunk, err := ioutil.ReadAll(r.Body)
-		if err != nil {
-			return Error500{"failed to read body"}
+		if inputFormat == formatCSV {
+			inputFormat = formatJSON
 		}
+	}
 
-		err = json.Unmarshal(chunk, v)
-	} else if inputFormat == formatXML {
-		chunk, err := ioutil.ReadAll(r.Body)
-		if err != nil {
-			return Error500{"failed to read body"}
-		}
+	err = decompressBody(r)
+	if err != nil {
+		return err
+	}
 
-		err = xml.Unmarshal(chunk, v)
-	} else if inputFormat == formatFORM {
+	if inputFormat == formatFORM {
 		err = r.ParseForm()
-		if err == nil {
-			err = parseForm(r.PostForm, v)
+		if err == errBodyTooLarge {
+			return NewAPIError(413, "request body too large")
+		} else if err != nil {
+			return NewAPIError(400, "failed to parse body: "+err.Error())
 		}
-	} else {
-		return errors.New("unknown output format")
+		return decodeForm(r.PostForm, v, coercionOf(r), parseLimits())
 	}
+	chunk, err := readBody(r)
 	if err != nil {
-		return Error500{"failed to parse body: " + err.Error()}
+		return err
 	}
-	return nil
+	return decodeBody(inputFormat, chunk, v, coercionOf(r), parseLimits(), routerFromRequest(r).getXMLOptions())
 }
 
 func getFormat(r *http.Request, field string) (format int, found bool) {
@@ -166,6 +266,12 @@ func getFormat(r *http.Request, field string) (format int, found bool) {
 				return formatXML, true
 			} else if format == "application/x-www-form-urlencoded" {
 				return formatFORM, true
+			} else if format == "application/vnd.api+json" {
+				return formatJSONAPI, true
+			} else if format == "application/hal+json" {
+				return formatHAL, true
+			} else if format == "text/csv" {
+				return formatCSV, true
 			}
 		}
 	}
@@ -176,56 +282,96 @@ func outputContentType(w http.ResponseWriter, code int, data []byte, format stri
 	var err error
 
 	w.Header().Set("Content-Type", format)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
 	w.WriteHeader(code)
 	_, err = w.Write(data)
 	return err
 }
 
-func output(w http.ResponseWriter, code int, data interface{}, format int) error {
+func output(w http.ResponseWriter, r *http.Request, code int, data interface{}, format int) error {
 	var chunk []byte
 	var err error
 
-	if format == formatJSON {
+	// the format is negotiated with the Accept header
+	addVary(w.Header(), "Accept")
+	if format == formatCSV {
+		if csvEncodable(data) == true {
+			return outputCSV(w, r, code, data)
+		}
+		format = formatJSON
+	}
+	limit := stateFromRequest(r).responseLimit
+	if limit.max > 0 && (format == formatJSON || format == formatXML) && isList(data) == true {
+		if format == formatJSON {
+			w.Header().Set("Content-Type", "aplication/json")
+		} else {
+			w.Header().Set("Content-Type", "aplication/xml")
+		}
+		return outputList(w, r, code, data, format, limit)
+	}
+	if format == formatJSON && routerFromRequest(r).isDev() == true {
+		chunk, err = json.MarshalIndent(data, "", "  ")
+		w.Header().Set("Content-Type", "aplication/json")
+	} else if format == formatJSON {
 		chunk, err = json.Marshal(data)
 		w.Header().Set("Content-Type", "aplication/json")
 	} else if format == formatXML {
-		chunk, err = xml.Marshal(data)
+		chunk, err = marshalXML(data, routerFromRequest(r).getXMLOptions())
 		w.Header().Set("Content-Type", "aplication/xml")
+	} else if format == formatJSONAPI {
+		chunk, err = marshalJSONAPI(r, code, data)
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+	} else if format == formatHAL {
+		chunk, err = marshalHAL(data)
+		w.Header().Set("Content-Type", "application/hal+json")
 	} else {
 		return errors.New("unknown output format")
 	}
 	if err != nil {
 		return err
 	}
-	w.WriteHeader(code)
-	_, err = w.Write(chunk)
-
-	return err
+	if limit.max > 0 && int64(len(chunk)) > limit.max {
+		oversized(r, limit)
+		if limit.policy != ResponseSizeStream {
+			return outputTooLarge(w, r, format)
+		}
+	}
+	return writeChunk(w, r, code, chunk)
 }
 
 func handler(fn Controller) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
 		outputFormat, _ := getFormat(r, "Accept")
+		state := &requestState{w: w}
+		state.timing.start = time.Now()
+		r = r.WithContext(context.WithValue(r.Context(), stateKey, state))
+		defer checkSlow(r, state)
+		if routerFromRequest(r).getErrorReporting() != nil {
+			defer reportPanics(r)
+		}
 		resp, err := fn(r, Params{p})
+		state.timing.controller = time.Since(state.timing.start)
+		if state.jsonAPI == true {
+			outputFormat = formatJSONAPI
+		}
 		if err != nil {
-			if err2, ok := err.(ErrorTransparent); ok == true {
-				log.Printf("error: %s, %+v\n", err2, err2.Parent())
-			} else {
-				log.Printf("error: %s\n", err)
-			}
-			if err2, ok := err.(Error); ok == true {
-				err3 := output(w, err2.StatusCode(), err2, outputFormat)
-				if err3 != nil {
-					log.Println("error while writing error:", err3)
-				}
-				return
-			}
-			err2 := output(w, 500, NewError500(), outputFormat)
-			if err2 != nil {
-				log.Println("error while writing error:", err2)
-			}
+			writeError(w, r, err, outputFormat)
 			return
 		}
+		for {
+			wrapper, ok := resp.(respWrapper)
+			if ok == false {
+				break
+			}
+			applyHeader(w.Header(), wrapper.header())
+			resp = wrapper.unwrap()
+		}
+		if tagged, ok := resp.(RespCacheTags); ok == true {
+			setCacheTags(w.Header(), tagged.CacheTags())
+		}
+		if routerFromRequest(r).isDev() == true {
+			noCache(w.Header())
+		}
 		statusCode := 200
 		location := ""
 		if resp2, ok := resp.(Resp); ok == true {
@@ -234,6 +380,19 @@ func handler(fn Controller) httprouter.Handle {
 				location = resp2.Location()
 			}
 		}
+		_, isStream := resp.(RespStream)
+		_, isRaw := resp.(RespCType)
+		_, isReaderAt := resp.(RespReaderAt)
+		if isStream == false && isRaw == false && isReaderAt == false {
+			err = validateResponse(r, statusCode, resp)
+			if err != nil {
+				err2 := output(w, r, 500, NewError500(), outputFormat)
+				if err2 != nil {
+					log.Println("error while writing error:", err2)
+				}
+				return
+			}
+		}
 		if resp2, ok := resp.(ICookieSetter); ok == true {
 			cookies := resp2.GetCookies()
 			if cookies != nil {
@@ -259,65 +418,139 @@ func handler(fn Controller) httprouter.Handle {
 		if location != "" {
 			w.Header().Add("Location", location)
 		}
-		if resp3, ok := resp.(RespCType); ok == true {
+		if resp3, ok := resp.(RespReaderAt); ok == true && statusCode == 200 {
+			outputReaderAt(w, r, resp3)
+			publishEvents(w, r, state)
+			return
+		}
+		if resp3, ok := resp.(RespStream); ok == true {
+			err = outputStream(w, r, statusCode, resp3)
+		} else if resp3, ok := resp.(RespCType); ok == true {
+			if state.digest == true {
+				setDigest(w.Header(), resp3.Data())
+			}
 			err = outputContentType(w, statusCode, resp3.Data(), resp3.ContentType())
 		} else {
-			err = output(w, statusCode, resp, outputFormat)
+			err = output(w, r, statusCode, resp, outputFormat)
 		}
-		if err != nil {
+		if err == errClientGone {
+			if router := routerFromRequest(r); router != nil {
+				router.incr("rest.responses.aborted", state.tags())
+			}
+		} else if err != nil {
 			log.Println("error while writing data:", err)
+		} else if statusCode < 400 {
+			publishEvents(w, r, state)
 		}
 	}
 }
 
 // GET is an overload to httprouter. Please refer to httprouter.GET for more details about the path
-func (r *Router) GET(path string, ctrl Controller) {
-	r.Router.GET(path, handler(ctrl))
+func (r *Router) GET(path string, ctrl Controller) *Route {
+	return r.handleController("GET", path, ctrl)
 }
 
 // RawGET is an overload to httprouter. Please refer to httprouter.GET for more details about the path
 func (r *Router) RawGET(path string, ctrl httprouter.Handle) {
-	r.Router.GET(path, ctrl)
+	r.handle("GET", path, ctrl)
 }
 
 // HEAD is an overload to httprouter. Please refer to httprouter.HEAD for more details about the path
-func (r *Router) HEAD(path string, ctrl Controller) {
-	r.Router.HEAD(path, handler(ctrl))
+func (r *Router) HEAD(path string, ctrl Controller) *Route {
+	return r.handleController("HEAD", path, ctrl)
 }
 
 // RawHEAD is an overload to httprouter. Please refer to httprouter.HEAD for more details about the path
 func (r *Router) RawHEAD(path string, ctrl httprouter.Handle) {
-	r.Router.HEAD(path, ctrl)
+	r.handle("HEAD", path, ctrl)
 }
 
 // POST is an overload to httprouter. Please refer to httprouter.POST for more details about the path
-func (r *Router) POST(path string, ctrl Controller) {
-	r.Router.POST(path, handler(ctrl))
+func (r *Router) POST(path string, ctrl Controller) *Route {
+	return r.handleController("POST", path, ctrl)
 }
 
 // RawPOST is an overload to httprouter. Please refer to httprouter.POST for more details about the path
 func (r *Router) RawPOST(path string, ctrl httprouter.Handle) {
-	r.Router.POST(path, ctrl)
+	r.handle("POST", path, ctrl)
 }
 
 // PUT is an overload to httprouter. Please refer to httprouter.PUT for more details about the path
-func (r *Router) PUT(path string, ctrl Controller) {
-	r.Router.PUT(path, handler(ctrl))
+func (r *Router) PUT(path string, ctrl Controller) *Route {
+	return r.handleController("PUT", path, ctrl)
 }
 
 // RawPUT is an overload to httprouter. Please refer to httprouter.PUT for more details about the path
 func (r *Router) RawPUT(path string, ctrl httprouter.Handle) {
-	r.Router.PUT(path, ctrl)
+	r.handle("PUT", path, ctrl)
 }
 
 // DELETE is an overload to httprouter. Please refer to httprouter.DELETE for more details about the path
-func (r *Router) DELETE(path string, ctrl Controller) {
-	r.Router.DELETE(path, handler(ctrl))
+func (r *Router) DELETE(path string, ctrl Controller) *Route {
+	return r.handleController("DELETE", path, ctrl)
 }
 
 // RawDELETE is an overload to httprouter. Please refer to httprouter.DELETE for more details about the path
 func (r *Router) RawDELETE(path string, ctrl httprouter.Handle) {
-	r.Router.DELETE(path, ctrl)
+	r.handle("DELETE", path, ctrl)
+}
+
+// headResponseWriter discards the body so that HEAD requests can reuse the GET controllers.
+// The headers, including Content-Length, are still written as for the GET request.
+type headResponseWriter struct {
+	http.ResponseWriter
+}
+
+func (w headResponseWriter) Write(data []byte) (int, error) {
+	return len(data), nil
+}
+
+// ServeHTTP makes the router implement http.Handler.
+// HEAD requests without a dedicated HEAD route are served by the matching GET route, without sending the body.
+// Routes can be registered and removed while serving.
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	req = withRouter(req, r)
+	r.mu.RLock()
+	recorder := r.recorder
+	headers := r.defaultHeaders
+	cors := r.cors
+	r.mu.RUnlock()
+	if recorder != nil {
+		var done func()
+		w, req, done = recorder.capture(w, req)
+		defer done()
+	}
+	setHeaders(w, headers)
+	if cors != nil && cors.serve(w, req) == true {
+		return
+	}
+	if r.serveMaintenance(w, req) == true {
+		return
+	}
+
+	// the routes can be registered at runtime, the tree of httprouter is only read while holding the lock
+	r.mu.RLock()
+	handle, p, _ := r.Router.Lookup(req.Method, req.URL.Path)
+	if handle == nil && req.Method == "HEAD" {
+		if handle, p, _ = r.Router.Lookup("GET", req.URL.Path); handle != nil {
+			w = headResponseWriter{w}
+		}
+	}
+	if handle == nil {
+		// not found, method not allowed, redirections...
+		defer r.mu.RUnlock()
+		r.Router.ServeHTTP(w, req)
+		return
+	}
+	r.mu.RUnlock()
+	if r.Router.PanicHandler != nil {
+		defer func() {
+			if rcv := recover(); rcv != nil {
+				r.Router.PanicHandler(w, req, rcv)
+			}
+		}()
+	}
+	handle(w, req, p)
 }
 
 // New creates a new router.
@@ -0,0 +1,368 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// RouteOptions are the per-route settings, they can be changed while serving
+type RouteOptions struct {
+	// Timeout is the maximum duration of the controller, the client gets a 503 once it is elapsed.
+	// The context of the request is canceled at the same time.
+	Timeout time.Duration
+	// MaxBodySize is the maximum size of the request body in bytes, bigger bodies are rejected with a 413 by Parse.
+	MaxBodySize int64
+	// Middlewares are run before the controller, in order. Authentication and rate limiting are set up this way.
+	Middlewares []Middleware
+	// Cache sets the caching headers of the successful GET responses, with a maximum age of CacheMaxAge.
+	Cache       *CacheOptions
+	CacheMaxAge time.Duration
+	// Deprecation marks the route as deprecated if not nil
+	Deprecation *Deprecation
+	// SlowThreshold is the duration above which the requests are logged as slow, see WithSlowThreshold
+	SlowThreshold time.Duration
+	// Concurrency limits the number of requests served at the same time if not nil
+	Concurrency *ConcurrencyLimit
+	// Critical exempts the route from load shedding
+	Critical bool
+	// Lenient coerces the strings of the bodies and query parameters into numbers and booleans, see Lenient
+	Lenient bool
+	// Coalesce makes the identical concurrent GET requests share one execution of the controller, see Coalesce.
+	// CoalesceKey identifies the identical requests if not nil, see CoalesceBy.
+	Coalesce    bool
+	CoalesceKey func(r *http.Request) string
+	// Flag is the feature flag the route is served behind, if not empty. The requests get a FlagStatus error while
+	// it is disabled, 404 if 0.
+	Flag       string
+	FlagStatus int
+	// Mirror gets a copy of the requests, in the background, see Mirror
+	Mirror http.Handler
+	// MaxResponseSize is the maximum size of the encoded responses in bytes, the larger ones are handled according to
+	// ResponseSizePolicy. See WithMaxResponseSize.
+	MaxResponseSize    int64
+	ResponseSizePolicy ResponseSizePolicy
+}
+
+// Route is a registered route, its options are set through its fluent methods:
+//
+//	router.GET("/reports/:id", getReport).WithTimeout(30 * time.Second).Use(auth)
+type Route struct {
+	method string
+	path   string
+	handle httprouter.Handle
+
+	mu      sync.RWMutex
+	options RouteOptions
+	sem     *concurrencyLimiter
+	flights map[string]*flight
+}
+
+// Method returns the method of the route
+func (rt *Route) Method() string {
+	return rt.method
+}
+
+// Path returns the path of the route, as given at the registration
+func (rt *Route) Path() string {
+	return rt.path
+}
+
+// Options returns the current options of the route
+func (rt *Route) Options() RouteOptions {
+	rt.mu.RLock()
+	defer rt.mu.RUnlock()
+	return rt.options
+}
+
+// WithOptions replaces all the options of the route
+func (rt *Route) WithOptions(options RouteOptions) *Route {
+	rt.mu.Lock()
+	rt.options = options
+	rt.mu.Unlock()
+	return rt
+}
+
+func (rt *Route) update(fn func(options *RouteOptions)) *Route {
+	rt.mu.Lock()
+	fn(&rt.options)
+	rt.mu.Unlock()
+	return rt
+}
+
+// WithTimeout sets the maximum duration of the controller
+func (rt *Route) WithTimeout(timeout time.Duration) *Route {
+	return rt.update(func(options *RouteOptions) {
+		options.Timeout = timeout
+	})
+}
+
+// WithMaxBodySize sets the maximum size of the request body
+func (rt *Route) WithMaxBodySize(size int64) *Route {
+	return rt.update(func(options *RouteOptions) {
+		options.MaxBodySize = size
+	})
+}
+
+// Use adds middlewares to the route (authentication, rate limiting...)
+func (rt *Route) Use(middlewares ...Middleware) *Route {
+	return rt.update(func(options *RouteOptions) {
+		options.Middlewares = append(append([]Middleware{}, options.Middlewares...), middlewares...)
+	})
+}
+
+// WithCache sets the caching headers of the successful GET responses, see Cacheable
+func (rt *Route) WithCache(maxAge time.Duration, opts CacheOptions) *Route {
+	return rt.update(func(options *RouteOptions) {
+		options.Cache = &opts
+		options.CacheMaxAge = maxAge
+	})
+}
+
+// wrap applies the options of the route around the controller, they are read at every request
+func (rt *Route) wrap(ctrl Controller) Controller {
+	return func(r *http.Request, p Params) (interface{}, error) {
+		state := stateFromRequest(r)
+		state.route = rt
+		options := rt.Options()
+		if router := routerFromRequest(r); router != nil {
+			router.mu.RLock()
+			if options.Timeout == 0 {
+				options.Timeout = time.Duration(router.config.RequestTimeout)
+			}
+			if options.MaxBodySize == 0 {
+				options.MaxBodySize = router.config.MaxBodySize
+			}
+			if options.MaxResponseSize == 0 {
+				options.MaxResponseSize = router.config.MaxResponseSize
+			}
+			router.mu.RUnlock()
+		}
+		state.responseLimit = responseLimit{options.MaxResponseSize, options.ResponseSizePolicy}
+		if options.Deprecation != nil {
+			options.Deprecation.deprecate(r, state)
+		}
+		if options.Flag != "" && FlagEnabled(r, options.Flag) == false {
+			if options.FlagStatus == 0 || options.FlagStatus == 404 {
+				return nil, NewAPIError(404, "not found")
+			}
+			return nil, NewAPIError(options.FlagStatus, "this feature is not available")
+		}
+		next := ctrl
+		if options.Coalesce == true {
+			next = rt.coalesced(options.CoalesceKey, next)
+		}
+		if options.Mirror != nil {
+			next = mirrored(options.Mirror, next)
+		}
+		next = validated(next)
+		for i := len(options.Middlewares) - 1; i >= 0; i-- {
+			next = options.Middlewares[i](next)
+		}
+		if options.Concurrency != nil && options.Concurrency.Max > 0 {
+			next = limitConcurrency(rt.limiter(*options.Concurrency), next)
+		}
+		if router := routerFromRequest(r); router != nil {
+			router.mu.RLock()
+			shedder := router.shedder
+			router.mu.RUnlock()
+			if shedder != nil {
+				next = shedLoad(shedder, options.Critical, next)
+			}
+		}
+		if options.MaxBodySize > 0 && r.Body != nil {
+			r.Body = readCloser{&limitReader{r.Body, options.MaxBodySize}, r.Body}
+		}
+
+		var resp interface{}
+		var err error
+		if options.Timeout > 0 {
+			resp, err = callWithTimeout(next, r, p, options.Timeout)
+		} else {
+			resp, err = next(r, p)
+		}
+		if err == nil && options.Cache != nil && (r.Method == "GET" || r.Method == "HEAD") {
+			resp = Cacheable(resp, options.CacheMaxAge, *options.Cache)
+		}
+		return resp, err
+	}
+}
+
+// callWithTimeout runs the controller in its own goroutine, in order to reply a 503 as soon as the timeout is elapsed.
+// The result of a controller returning too late is dropped. The controller works on a detached state, so that it
+// can't change the response once the 503 is sent.
+func callWithTimeout(ctrl Controller, r *http.Request, p Params, timeout time.Duration) (interface{}, error) {
+	type result struct {
+		resp  interface{}
+		err   error
+		panic interface{}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+	var tw *timeoutWriter
+	state, ok := r.Context().Value(stateKey).(*requestState)
+	if ok == true {
+		var w http.ResponseWriter
+		if state.w != nil {
+			tw = &timeoutWriter{w: state.w, h: cloneHeader(state.w.Header())}
+			w = tw
+		}
+		detached := state.detach(w)
+		ctx = context.WithValue(ctx, stateKey, detached)
+		defer func() {
+			if tw != nil {
+				tw.mu.Lock()
+				tw.timedOut = true
+				tw.mu.Unlock()
+			}
+		}()
+		state = detached
+	}
+	done := make(chan result, 1)
+	go func() {
+		var res result
+		defer func() {
+			if rcv := recover(); rcv != nil {
+				res.panic = rcv
+			}
+			done <- res
+		}()
+		res.resp, res.err = ctrl(r.WithContext(ctx), p)
+	}()
+	select {
+	case res := <-done:
+		if ok == true {
+			stateFromRequest(r).merge(state)
+		}
+		if tw != nil {
+			replaceHeader(tw.w.Header(), tw.h)
+		}
+		if res.panic != nil {
+			panic(res.panic)
+		}
+		return res.resp, res.err
+	case <-ctx.Done():
+		return nil, NewAPIError(503, "the request timed out")
+	}
+}
+
+// timeoutWriter is the writer of a controller run with a timeout. Its headers are copied to the response once the
+// controller returns in time, the informational responses (see EarlyHints) are only sent before the timeout.
+type timeoutWriter struct {
+	w http.ResponseWriter
+	h http.Header
+
+	mu       sync.Mutex
+	timedOut bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.h
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut == false {
+		replaceHeader(tw.w.Header(), tw.h)
+		tw.w.WriteHeader(code)
+	}
+}
+
+func (tw *timeoutWriter) Write(data []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut == true {
+		return 0, http.ErrHandlerTimeout
+	}
+	replaceHeader(tw.w.Header(), tw.h)
+	return tw.w.Write(data)
+}
+
+// replaceHeader replaces the values of dst by the ones of src
+func replaceHeader(dst, src http.Header) {
+	for name := range dst {
+		if _, ok := src[name]; ok == false {
+			delete(dst, name)
+		}
+	}
+	for name, values := range src {
+		dst[name] = append([]string(nil), values...)
+	}
+}
+
+// register adds a route, it is safe to call while the server is running.
+// Registering a path that was removed replaces the route.
+func (r *Router) register(rt *Route) *Route {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := rt.method + " " + rt.path
+	if _, ok := r.routes[key]; ok == true {
+		panic("a handle is already registered for path '" + rt.path + "'")
+	}
+	if r.routes == nil {
+		r.routes = make(map[string]*Route)
+		r.dispatchers = make(map[string]bool)
+	}
+	r.routes[key] = rt
+	if r.dispatchers[key] == true {
+		return rt
+	}
+	// httprouter does not allow to remove routes, so the handle registered in httprouter only dispatches to the
+	// current route of the path, which can be replaced or removed at runtime.
+	r.dispatchers[key] = true
+	r.Router.Handle(rt.method, rt.path, func(w http.ResponseWriter, req *http.Request, p httprouter.Params) {
+		r.mu.RLock()
+		current := r.routes[key]
+		r.mu.RUnlock()
+		if current != nil {
+			current.handle(w, req, p)
+			return
+		}
+		if r.Router.NotFound != nil {
+			r.Router.NotFound.ServeHTTP(w, req)
+		} else {
+			http.NotFound(w, req)
+		}
+	})
+	return rt
+}
+
+// handle registers a raw route
+func (r *Router) handle(method, path string, handle httprouter.Handle) *Route {
+	return r.register(&Route{
+		method: method,
+		path:   path,
+		handle: handle,
+	})
+}
+
+// handleController registers a route served by a controller
+func (r *Router) handleController(method, path string, ctrl Controller) *Route {
+	rt := &Route{
+		method: method,
+		path:   path,
+	}
+	rt.handle = handler(rt.wrap(ctrl))
+	return r.register(rt)
+}
+
+// Remove unregisters the route matching method and path (as given at the registration), it is safe to call while
+// the server is running. The requests to a removed route are handled as not found. It returns false if there was no
+// such route.
+func (r *Router) Remove(method, path string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := method + " " + path
+	if _, ok := r.routes[key]; ok == false {
+		return false
+	}
+	delete(r.routes, key)
+	return true
+}
@@ -0,0 +1,54 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeoutDetachesState(t *testing.T) {
+	tests := []struct {
+		name  string
+		delay time.Duration
+		code  int
+		vary  string
+	}{
+		{"in time", 0, 200, "Accept-Language"},
+		{"too late", 50 * time.Millisecond, 503, ""},
+	}
+	for _, test := range tests {
+		returned := make(chan struct{})
+		r := New()
+		r.GET("/", func(req *http.Request, p Params) (interface{}, error) {
+			defer close(returned)
+			time.Sleep(test.delay)
+			EarlyHints(req, Preload("/app.css", "style"))
+			Vary(req, "Accept-Language")
+			Emit(req, "viewed", nil)
+			return "ok", nil
+		}).WithTimeout(10 * time.Millisecond)
+
+		// the recorder of httptest takes the 103 for the final response
+		srv := httptest.NewServer(r)
+		resp, err := http.Get(srv.URL)
+		<-returned
+		srv.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != test.code {
+			t.Errorf("%s: got %d, expected %d", test.name, resp.StatusCode, test.code)
+		}
+		found := ""
+		for _, value := range resp.Header["Vary"] {
+			if value == "Accept-Language" {
+				found = value
+			}
+		}
+		if found != test.vary {
+			t.Errorf("%s: got Vary %v", test.name, resp.Header["Vary"])
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Hook is a lifecycle hook of the router, see OnStart and OnStop
+type Hook func(ctx context.Context) error
+
+// OnStart adds a hook run by Serve before accepting connections (warming caches, opening connections...).
+// The hooks are run in the order they were added, Serve fails if one of them fails.
+func (r *Router) OnStart(fn Hook) {
+	r.mu.Lock()
+	r.onStart = append(r.onStart, fn)
+	r.mu.Unlock()
+}
+
+// OnStop adds a hook run by Serve once the server is stopped and the requests drained (flushing buffers, closing
+// connections...). The hooks are run in the reverse order they were added, before the modules are closed.
+// If a start hook fails, only the stop hooks added before the following start hooks are run: add the stop hook of a
+// resource right after its start hook.
+func (r *Router) OnStop(fn Hook) {
+	r.mu.Lock()
+	r.onStop = append(r.onStop, stopHook{fn, len(r.onStart)})
+	r.mu.Unlock()
+}
+
+// stopHook is a stop hook with the number of start hooks added before it, which must have run for it to run
+type stopHook struct {
+	fn     Hook
+	starts int
+}
+
+// SetShutdownTimeout sets the maximum duration of the graceful shutdown of Serve: draining the requests in progress,
+// running the stop hooks and closing the modules. It is 30 seconds by default.
+func (r *Router) SetShutdownTimeout(timeout time.Duration) {
+	r.mu.Lock()
+	r.shutdownTimeout = timeout
+	r.mu.Unlock()
+}
+
+// ListenAndServe serves the router on addr until the process receives SIGINT or SIGTERM, see Serve.
+// The server gets the timeouts and limits of the configuration of the router, and listens on its address if addr
+// is empty. Unix domain sockets and systemd sockets are supported, see Listen.
+func (r *Router) ListenAndServe(addr string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		select {
+		case <-signals:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	srv := r.server(addr)
+	if strings.HasPrefix(srv.Addr, "unix:") == true || strings.HasPrefix(srv.Addr, "systemd") == true {
+		ln, err := Listen(srv.Addr)
+		if err != nil {
+			return err
+		}
+		return r.ServeListener(ctx, srv, ln)
+	}
+	return r.Serve(ctx, srv)
+}
+
+// Serve runs the start hooks, then serves the router with srv until ctx is canceled. It then shuts down gracefully:
+// srv stops accepting connections and drains the requests in progress, the stop hooks are run and the modules are
+// closed (see Shutdown).
+// The handler of srv is set to the router if it is nil. If srv has a TLS config, it must hold the certificates,
+// otherwise the certificate of the configuration of the router is used if set.
+func (r *Router) Serve(ctx context.Context, srv *http.Server) error {
+	r.mu.RLock()
+	tls := r.config.TLS
+	r.mu.RUnlock()
+	return r.serve(ctx, srv, func() error {
+		if srv.TLSConfig != nil {
+			return srv.ListenAndServeTLS("", "")
+		}
+		if tls.CertFile != "" {
+			return srv.ListenAndServeTLS(tls.CertFile, tls.KeyFile)
+		}
+		return srv.ListenAndServe()
+	})
+}
+
+// serve runs the lifecycle of the router around listen, which serves with srv until it is shut down
+func (r *Router) serve(ctx context.Context, srv *http.Server, listen func() error) error {
+	if srv.Handler == nil {
+		srv.Handler = r
+	}
+	r.mu.RLock()
+	onStart := r.onStart
+	timeout := r.shutdownTimeout
+	r.mu.RUnlock()
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+
+	for i, fn := range onStart {
+		if err := fn(ctx); err != nil {
+			r.stop(timeout, i)
+			return fmt.Errorf("failed to start: %s", err)
+		}
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- listen()
+	}()
+	var first error
+	select {
+	case err := <-done:
+		// the server failed to listen
+		first = err
+	case <-ctx.Done():
+		sctx, cancel := context.WithTimeout(context.Background(), timeout)
+		first = srv.Shutdown(sctx)
+		cancel()
+		if err := <-done; err != http.ErrServerClosed && first == nil {
+			first = err
+		}
+	}
+	if err := r.stop(timeout, len(onStart)); err != nil && first == nil {
+		first = err
+	}
+	return first
+}
+
+// stop runs in reverse order the stop hooks matching the started start hooks, started being their number, and closes
+// the modules. It returns the first error.
+func (r *Router) stop(timeout time.Duration, started int) error {
+	var first error
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	r.mu.RLock()
+	onStop := r.onStop
+	r.mu.RUnlock()
+	for i := len(onStop) - 1; i >= 0; i-- {
+		if onStop[i].starts > started {
+			continue
+		}
+		if err := onStop[i].fn(ctx); err != nil && first == nil {
+			first = fmt.Errorf("failed to stop: %s", err)
+		}
+	}
+	if err := r.Shutdown(); err != nil && first == nil {
+		first = err
+	}
+	return first
+}
@@ -0,0 +1,36 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestServeStartFailure(t *testing.T) {
+	var calls []string
+	hook := func(name string, err error) Hook {
+		return func(ctx context.Context) error {
+			calls = append(calls, name)
+			return err
+		}
+	}
+	r := New()
+	r.OnStop(hook("stop logs", nil))
+	r.OnStart(hook("start db", nil))
+	r.OnStop(hook("stop db", nil))
+	r.OnStart(hook("start cache", errors.New("unreachable")))
+	r.OnStop(hook("stop cache", nil))
+	r.OnStart(hook("start queue", nil))
+	r.OnStop(hook("stop queue", nil))
+
+	err := r.Serve(context.Background(), &http.Server{Addr: "127.0.0.1:0"})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	expected := []string{"start db", "start cache", "stop db", "stop logs"}
+	if reflect.DeepEqual(calls, expected) == false {
+		t.Errorf("got %v, expected %v", calls, expected)
+	}
+}
@@ -0,0 +1,155 @@
+package rest
+
+import (
+	"math/rand"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LoadShedder protects the router from overload: while the number of requests in progress or the 99th percentile of
+// the latency of the controllers is above its thresholds, a fraction of the requests is rejected with a 503.
+// The critical routes (see Route.Critical) are never rejected, but their requests count in the load.
+type LoadShedder struct {
+	// MaxInFlight is the number of requests in progress above which the router is overloaded, 0 for no limit
+	MaxInFlight int
+	// MaxLatency is the 99th percentile of the latency above which the router is overloaded, 0 for no limit
+	MaxLatency time.Duration
+	// Fraction is the fraction of the requests rejected while overloaded, between 0 and 1
+	Fraction float64
+	// RetryAfter is sent in the Retry-After header of the rejected requests, 0 for no header
+	RetryAfter time.Duration
+
+	inFlight int64
+
+	mu        sync.Mutex
+	rand      *rand.Rand
+	latencies []latencySample
+	next      int
+	p99       time.Duration
+	computed  time.Time
+}
+
+// latencyWindow is the number of latencies the 99th percentile is computed on, latencyMaxAge the age after which they
+// are ignored, so that the percentile recovers once the load has dropped
+const (
+	latencyWindow = 1024
+	latencyMaxAge = time.Minute
+)
+
+type latencySample struct {
+	d  time.Duration
+	at time.Time
+}
+
+// NewLoadShedder creates a LoadShedder rejecting half of the requests while overloaded
+func NewLoadShedder(maxInFlight int, maxLatency time.Duration) *LoadShedder {
+	return &LoadShedder{
+		MaxInFlight: maxInFlight,
+		MaxLatency:  maxLatency,
+		Fraction:    0.5,
+		RetryAfter:  time.Second,
+	}
+}
+
+// SetLoadShedder sets the load shedder of the routes of the router, nil disables it
+func (r *Router) SetLoadShedder(s *LoadShedder) {
+	r.mu.Lock()
+	r.shedder = s
+	r.mu.Unlock()
+}
+
+// Critical exempts the route from load shedding (health checks, payments...)
+func (rt *Route) Critical() *Route {
+	return rt.update(func(options *RouteOptions) {
+		options.Critical = true
+	})
+}
+
+// InFlight returns the number of requests in progress
+func (s *LoadShedder) InFlight() int {
+	return int(atomic.LoadInt64(&s.inFlight))
+}
+
+// P99 returns the 99th percentile of the latency of the requests of the last minute, 0 if there were none. It is
+// computed at most once per second.
+func (s *LoadShedder) P99() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if now.Sub(s.computed) < time.Second {
+		return s.p99
+	}
+	sorted := make([]time.Duration, 0, len(s.latencies))
+	for _, sample := range s.latencies {
+		if now.Sub(sample.at) <= latencyMaxAge {
+			sorted = append(sorted, sample.d)
+		}
+	}
+	s.p99 = 0
+	if len(sorted) > 0 {
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		s.p99 = sorted[len(sorted)*99/100]
+	}
+	s.computed = now
+	return s.p99
+}
+
+func (s *LoadShedder) record(d time.Duration) {
+	sample := latencySample{d, time.Now()}
+	s.mu.Lock()
+	if len(s.latencies) < latencyWindow {
+		s.latencies = append(s.latencies, sample)
+	} else {
+		s.latencies[s.next] = sample
+		s.next = (s.next + 1) % latencyWindow
+	}
+	s.mu.Unlock()
+}
+
+// overloaded returns whether the router is above one of the thresholds
+func (s *LoadShedder) overloaded() bool {
+	if s.MaxInFlight > 0 && s.InFlight() > s.MaxInFlight {
+		return true
+	}
+	return s.MaxLatency > 0 && s.P99() > s.MaxLatency
+}
+
+// shed returns whether the request is rejected
+func (s *LoadShedder) shed() bool {
+	if s.overloaded() == false {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.rand == nil {
+		s.rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return s.rand.Float64() < s.Fraction
+}
+
+// shedLoad runs next, unless the router is overloaded and the request is drawn to be rejected. The requests of the
+// critical routes are counted and timed but never rejected.
+func shedLoad(s *LoadShedder, critical bool, next Controller) Controller {
+	return func(r *http.Request, p Params) (interface{}, error) {
+		atomic.AddInt64(&s.inFlight, 1)
+		defer atomic.AddInt64(&s.inFlight, -1)
+		if critical == false && s.shed() == true {
+			state := stateFromRequest(r)
+			if s.RetryAfter > 0 && state.w != nil {
+				state.w.Header().Set("Retry-After", seconds(s.RetryAfter))
+			}
+			if router := routerFromRequest(r); router != nil {
+				router.incr("rest.requests.shed", state.tags())
+			}
+			return nil, NewAPIError(503, "the server is overloaded, please retry later")
+		}
+		start := time.Now()
+		defer func() {
+			s.record(time.Since(start))
+		}()
+		return next(r, p)
+	}
+}
@@ -0,0 +1,147 @@
+package rest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// URLSigner produces and checks expiring signed URLs, to give access to protected routes without authentication
+// headers (download links sent by email...)
+type URLSigner struct {
+	key []byte
+}
+
+// NewURLSigner creates a URLSigner signing with key, which must be kept secret
+func NewURLSigner(key []byte) *URLSigner {
+	return &URLSigner{key: key}
+}
+
+// DefaultURLSigner is used by SignURL and Signed, it must be set before using them
+var DefaultURLSigner *URLSigner
+
+// SignURL returns a signed URL of the route of the DefaultURLSigner, see URLSigner.SignURL
+func SignURL(route string, params map[string]string, ttl time.Duration) (string, error) {
+	if DefaultURLSigner == nil {
+		return "", errors.New("no DefaultURLSigner set")
+	}
+	return DefaultURLSigner.SignURL(route, params, ttl)
+}
+
+// Signed is a Middleware rejecting the requests without a valid signature of the DefaultURLSigner, see
+// URLSigner.Middleware
+func Signed(next Controller) Controller {
+	return func(r *http.Request, p Params) (interface{}, error) {
+		if DefaultURLSigner == nil {
+			return nil, errorTransparent{NewError500(), errors.New("no DefaultURLSigner set")}
+		}
+		return DefaultURLSigner.Middleware(next)(r, p)
+	}
+}
+
+// SignURL returns the URL of route (as registered, /files/:id for instance) signed for ttl. The params fill the
+// parameters of the path, the others are added to the query, the returned URL is relative:
+//
+//	link, err := signer.SignURL("/files/:id", map[string]string{"id": "42", "name": "report.pdf"}, 24*time.Hour)
+func (s *URLSigner) SignURL(route string, params map[string]string, ttl time.Duration) (string, error) {
+	used := make(map[string]bool)
+	segments := strings.Split(route, "/")
+	for i, segment := range segments {
+		if len(segment) < 2 || (segment[0] != ':' && segment[0] != '*') {
+			continue
+		}
+		name := segment[1:]
+		value, ok := params[name]
+		if ok == false {
+			return "", fmt.Errorf("missing parameter %q", name)
+		}
+		used[name] = true
+		if segment[0] == '*' {
+			// the catch-all parameters include the leading slash
+			segments[i] = escapePath(strings.TrimPrefix(value, "/"))
+		} else {
+			segments[i] = url.PathEscape(value)
+		}
+	}
+
+	query := make(url.Values)
+	for name, value := range params {
+		if used[name] == false {
+			query.Set(name, value)
+		}
+	}
+	query.Set("expires", strconv.FormatInt(time.Now().Add(ttl).Unix(), 10))
+	path := strings.Join(segments, "/")
+	query.Set("signature", s.sign(path, query))
+	return path + "?" + query.Encode(), nil
+}
+
+func escapePath(p string) string {
+	parts := strings.Split(p, "/")
+	for i := range parts {
+		parts[i] = url.PathEscape(parts[i])
+	}
+	return strings.Join(parts, "/")
+}
+
+// sign returns the signature of the path and the query, without its signature parameter
+func (s *URLSigner) sign(path string, query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		if name != "signature" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	// every part is prefixed by its length, so that no path, name or value can be split differently with the same
+	// signature
+	mac := hmac.New(sha256.New, s.key)
+	writePart := func(part string) {
+		mac.Write([]byte(strconv.Itoa(len(part)) + ":" + part))
+	}
+	writePart(path)
+	for _, name := range names {
+		for _, value := range query[name] {
+			writePart(name)
+			writePart(value)
+		}
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature and the expiration of the URL of the request
+func (s *URLSigner) Verify(r *http.Request) error {
+	query := r.URL.Query()
+	signature, err := hex.DecodeString(query.Get("signature"))
+	if err != nil || len(signature) == 0 {
+		return NewAPIError(403, "invalid signature")
+	}
+	expected, _ := hex.DecodeString(s.sign(r.URL.EscapedPath(), query))
+	if hmac.Equal(signature, expected) == false {
+		return NewAPIError(403, "invalid signature")
+	}
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return NewAPIError(403, "the link has expired")
+	}
+	return nil
+}
+
+// Middleware returns a Middleware rejecting with a 403 the requests without a valid signature
+func (s *URLSigner) Middleware(next Controller) Controller {
+	return func(r *http.Request, p Params) (interface{}, error) {
+		if err := s.Verify(r); err != nil {
+			return nil, err
+		}
+		return next(r, p)
+	}
+}
@@ -0,0 +1,69 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignedURL(t *testing.T) {
+	signer := NewURLSigner([]byte("secret"))
+	router := New()
+	router.GET("/files/:id", func(r *http.Request, p Params) (interface{}, error) {
+		return "ok", nil
+	}).Use(signer.Middleware)
+
+	valid, err := signer.SignURL("/files/:id", map[string]string{"id": "a b", "name": "x"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, _ := signer.SignURL("/files/:id", map[string]string{"id": "1"}, -time.Hour)
+	other, _ := NewURLSigner([]byte("other")).SignURL("/files/:id", map[string]string{"id": "1"}, time.Hour)
+	if _, err = signer.SignURL("/files/:id", nil, time.Hour); err == nil {
+		t.Error("a URL was signed without its path parameter")
+	}
+
+	tests := []struct {
+		name string
+		url  string
+		code int
+	}{
+		{"valid", valid, 200},
+		{"expired", expired, 403},
+		{"other key", other, 403},
+		{"unsigned", "/files/1", 403},
+		{"other path", strings.Replace(valid, "a%20b", "c", 1), 403},
+		{"changed query", strings.Replace(valid, "name=x", "name=y", 1), 403},
+		{"added query", valid + "&admin=1", 403},
+		{"invalid signature", strings.Replace(valid, "signature=", "signature=zz", 1), 403},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", test.url, nil))
+		if w.Code != test.code {
+			t.Errorf("%s: got %d, expected %d", test.name, w.Code, test.code)
+		}
+	}
+}
+
+func TestSignedURLAmbiguity(t *testing.T) {
+	signer := NewURLSigner([]byte("secret"))
+	tests := []struct {
+		a, b url.Values
+	}{
+		{url.Values{"a": {"1\nb=2"}}, url.Values{"a": {"1"}, "b": {"2"}}},
+		{url.Values{"a": {"b=c"}}, url.Values{"a=b": {"c"}}},
+		{url.Values{"a": {"1", "2"}}, url.Values{"a": {"1\na=2"}}},
+	}
+	for _, test := range tests {
+		if signer.sign("/p", test.a) == signer.sign("/p", test.b) {
+			t.Errorf("%v and %v have the same signature", test.a, test.b)
+		}
+	}
+	if signer.sign("/p\na=1", nil) == signer.sign("/p", url.Values{"a": {"1"}}) {
+		t.Error("the path and the query can be split differently")
+	}
+}
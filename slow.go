@@ -0,0 +1,53 @@
+package rest
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// WithSlowThreshold sets the duration above which the requests of the route are logged as slow, with the time spent
+// parsing the body (bind), in the controller and writing the response (encode), and counted in the metrics
+// (rest.requests.slow). 0 disables the detection.
+func (rt *Route) WithSlowThreshold(threshold time.Duration) *Route {
+	return rt.update(func(options *RouteOptions) {
+		options.SlowThreshold = threshold
+	})
+}
+
+// requestTiming is the timing breakdown of a request
+type requestTiming struct {
+	start      time.Time
+	bind       int64 // nanoseconds spent in Parse, updated atomically as the controller may outlive a timeout
+	controller time.Duration
+}
+
+// addBind adds the time spent parsing the body since start
+func (t *requestTiming) addBind(start time.Time) {
+	atomic.AddInt64(&t.bind, int64(time.Since(start)))
+}
+
+// checkSlow logs and counts the request if it exceeded the slow threshold of its route
+func checkSlow(r *http.Request, state *requestState) {
+	if state.route == nil {
+		return
+	}
+	threshold := state.route.Options().SlowThreshold
+	total := time.Since(state.timing.start)
+	if threshold <= 0 || total < threshold {
+		return
+	}
+
+	bind := time.Duration(atomic.LoadInt64(&state.timing.bind))
+	controller := state.timing.controller - bind
+	encode := total - state.timing.controller
+	Log(r).Logf(LevelWarn, "slow request: %s %s took %s (bind %s, controller %s, encode %s)\n",
+		r.Method, r.URL.Path, total, bind, controller, encode)
+	if router := routerFromRequest(r); router != nil {
+		tags := state.tags()
+		router.incr("rest.requests.slow", tags)
+		router.timing("rest.requests.slow.bind", bind, tags)
+		router.timing("rest.requests.slow.controller", controller, tags)
+		router.timing("rest.requests.slow.encode", encode, tags)
+	}
+}
@@ -0,0 +1,80 @@
+package rest
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// RespStream is an interface allowing to stream the response body instead of encoding it in memory
+type RespStream interface {
+	ContentType() string
+	Stream(w io.Writer) error
+}
+
+// RespTrailer is implemented by the streamed responses sending HTTP trailers after their body (checksum, row
+// count, error status...). The trailers must be declared by Trailers, and set with SetTrailer from Stream.
+type RespTrailer interface {
+	Trailers() []string
+}
+
+// streamWriter is the writer given to RespStream.Stream, it fails as soon as the client disconnects
+type streamWriter struct {
+	w        http.ResponseWriter
+	done     <-chan struct{}
+	trailers map[string]bool
+}
+
+func (s *streamWriter) Write(data []byte) (int, error) {
+	select {
+	case <-s.done:
+		return 0, errClientGone
+	default:
+	}
+	return s.w.Write(data)
+}
+
+// Flush implements http.Flusher
+func (s *streamWriter) Flush() {
+	if flusher, ok := s.w.(http.Flusher); ok == true {
+		flusher.Flush()
+	}
+}
+
+// SetTrailer sets the value of a trailer, it is meant to be called from the Stream method of a RespStream with the
+// writer it got. The trailer must have been declared through RespTrailer.
+func SetTrailer(w io.Writer, name, value string) error {
+	s, ok := w.(*streamWriter)
+	if ok == false {
+		return errors.New("trailers can only be set on the writer of a stream")
+	}
+	name = http.CanonicalHeaderKey(name)
+	if s.trailers[name] == false {
+		return errors.New("undeclared trailer " + name)
+	}
+	s.w.Header().Set(name, value)
+	return nil
+}
+
+func outputStream(w http.ResponseWriter, r *http.Request, code int, resp RespStream) error {
+	s := &streamWriter{
+		w:        w,
+		done:     r.Context().Done(),
+		trailers: make(map[string]bool),
+	}
+	if resp2, ok := resp.(RespTrailer); ok == true {
+		var names []string
+		for _, name := range resp2.Trailers() {
+			name = http.CanonicalHeaderKey(name)
+			s.trailers[name] = true
+			names = append(names, name)
+		}
+		if len(names) != 0 {
+			w.Header().Set("Trailer", strings.Join(names, ", "))
+		}
+	}
+	w.Header().Set("Content-Type", resp.ContentType())
+	w.WriteHeader(code)
+	return resp.Stream(s)
+}
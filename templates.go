@@ -0,0 +1,126 @@
+package rest
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Templates renders the HTML pages of a router, see Router.SetTemplates and HTML
+type Templates struct {
+	pattern string
+	funcs   template.FuncMap
+
+	mu       sync.RWMutex
+	tmpl     *template.Template
+	modified time.Time
+	files    int
+}
+
+// NewTemplates parses the templates matching pattern (see template.ParseGlob)
+func NewTemplates(pattern string, funcs template.FuncMap) (*Templates, error) {
+	t := &Templates{
+		pattern: pattern,
+		funcs:   funcs,
+	}
+	err := t.Reload()
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Reload parses the templates again, the current templates are kept if it fails
+func (t *Templates) Reload() error {
+	modified, files := t.stat()
+	tmpl, err := template.New("").Funcs(t.funcs).ParseGlob(t.pattern)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	t.tmpl = tmpl
+	t.modified = modified
+	t.files = files
+	t.mu.Unlock()
+	return nil
+}
+
+// stat returns the last modification time and the number of the template files
+func (t *Templates) stat() (time.Time, int) {
+	var modified time.Time
+
+	files, _ := filepath.Glob(t.pattern)
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err == nil && info.ModTime().After(modified) {
+			modified = info.ModTime()
+		}
+	}
+	return modified, len(files)
+}
+
+// reloadIfChanged reloads the templates if a file was modified, added or removed since they were parsed
+func (t *Templates) reloadIfChanged() error {
+	modified, files := t.stat()
+	t.mu.RLock()
+	changed := modified.Equal(t.modified) == false || files != t.files
+	t.mu.RUnlock()
+	if changed == false {
+		return nil
+	}
+	return t.Reload()
+}
+
+// Render executes the template name with data
+func (t *Templates) Render(name string, data interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+
+	t.mu.RLock()
+	tmpl := t.tmpl
+	t.mu.RUnlock()
+	err := tmpl.ExecuteTemplate(&buf, name, data)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SetTemplates sets the templates used by HTML
+func (r *Router) SetTemplates(t *Templates) {
+	r.mu.Lock()
+	r.templates = t
+	r.mu.Unlock()
+}
+
+// HTML renders the template name of the router serving the request, the result is meant to be returned by the
+// controller:
+//
+//	return rest.HTML(r, "index.html", data)
+func HTML(r *http.Request, name string, data interface{}) (interface{}, error) {
+	var t *Templates
+
+	router := routerFromRequest(r)
+	if router != nil {
+		router.mu.RLock()
+		t = router.templates
+		router.mu.RUnlock()
+	}
+	if t == nil {
+		return nil, errorTransparent{NewError500(), errors.New("no templates set")}
+	}
+	if router.isDev() == true {
+		if err := t.reloadIfChanged(); err != nil {
+			return nil, errorTransparent{NewError500(), err}
+		}
+	}
+	page, err := t.Render(name, data)
+	if err != nil {
+		return nil, errorTransparent{NewError500(), err}
+	}
+	return rawResp{200, "text/html; charset=utf-8", page}, nil
+}
@@ -0,0 +1,61 @@
+package rest
+
+import (
+	"context"
+	"log"
+	"net/http"
+)
+
+// Tx is a transaction, *sql.Tx implements it.
+type Tx interface {
+	Commit() error
+	Rollback() error
+}
+
+// Transactional returns a Middleware running the controllers in a transaction started by begin.
+// The transaction is committed if the controller succeeds, and rolled back if it returns an error or panics.
+// The controllers get the transaction with Transaction:
+//
+//	router.Group("/", rest.Transactional(func(r *http.Request) (rest.Tx, error) {
+//		return db.BeginTx(r.Context(), nil)
+//	}))
+func Transactional(begin func(r *http.Request) (Tx, error)) Middleware {
+	return func(next Controller) Controller {
+		return func(r *http.Request, p Params) (resp interface{}, err error) {
+			tx, err := begin(r)
+			if err != nil {
+				return nil, errorTransparent{NewError500(), err}
+			}
+			defer func() {
+				if rcv := recover(); rcv != nil {
+					rollback(tx)
+					panic(rcv)
+				}
+			}()
+
+			resp, err = next(r.WithContext(context.WithValue(r.Context(), txKey, tx)), p)
+			if err != nil {
+				rollback(tx)
+				return nil, err
+			}
+			err = tx.Commit()
+			if err != nil {
+				return nil, errorTransparent{NewError500(), err}
+			}
+			return resp, nil
+		}
+	}
+}
+
+func rollback(tx Tx) {
+	err := tx.Rollback()
+	if err != nil {
+		log.Println("error while rolling back transaction:", err)
+	}
+}
+
+// Transaction returns the transaction started by Transactional, nil if there is none
+func Transaction(r *http.Request) Tx {
+	tx, _ := r.Context().Value(txKey).(Tx)
+	return tx
+}
@@ -0,0 +1,348 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldError is an error on a field of a request or a response
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// ValidationError is returned when a request does not match its OpenAPI description, its status code is 400
+type ValidationError struct {
+	Message string
+	Errors  []FieldError
+}
+
+func (e ValidationError) Error() string {
+	return e.Message
+}
+
+// StatusCode returns 400
+func (e ValidationError) StatusCode() int {
+	return 400
+}
+
+// ValidateRequests makes the router validate the requests against an OpenAPI document (loaded with LoadOpenAPI or
+// generated by Router.OpenAPI): parameters, content type and body. The requests not matching any path of the
+// document are not validated. nil disables the validation.
+func (r *Router) ValidateRequests(doc *OpenAPI) {
+	r.mu.Lock()
+	r.openapi = doc
+	r.mu.Unlock()
+}
+
+// validated validates the request before calling next. It runs after the middlewares of the route and once the body
+// is limited, so that the unauthenticated requests don't learn the schema and the bodies are not read unbounded.
+func validated(next Controller) Controller {
+	return func(r *http.Request, p Params) (interface{}, error) {
+		if err := validateRequest(r); err != nil {
+			return nil, err
+		}
+		return next(r, p)
+	}
+}
+
+// validateRequest validates the request against the OpenAPI document of its router, if any
+func validateRequest(r *http.Request) error {
+	var errs []FieldError
+
+	router := routerFromRequest(r)
+	if router == nil {
+		return nil
+	}
+	router.mu.RLock()
+	doc := router.openapi
+	router.mu.RUnlock()
+	if doc == nil {
+		return nil
+	}
+	item, op, pathParams := doc.find(r.Method, r.URL.Path)
+	if op == nil {
+		return nil
+	}
+
+	query := r.URL.Query()
+	for _, param := range append(append([]*Parameter{}, item.Parameters...), op.Parameters...) {
+		var raw string
+		var found bool
+
+		if param.In == "path" {
+			raw, found = pathParams[param.Name]
+		} else if param.In == "query" {
+			_, found = query[param.Name]
+			raw = query.Get(param.Name)
+		} else if param.In == "header" {
+			_, found = r.Header[http.CanonicalHeaderKey(param.Name)]
+			raw = r.Header.Get(param.Name)
+		} else if param.In == "cookie" {
+			cookie, err := r.Cookie(param.Name)
+			if found = err == nil; found == true {
+				raw = cookie.Value
+			}
+		}
+		field := param.In + "." + param.Name
+		if found == false {
+			if param.Required == true {
+				errs = append(errs, FieldError{field, "is required"})
+			}
+			continue
+		}
+		schema, err := doc.resolve(param.Schema)
+		if err != nil {
+			return errorTransparent{NewError500(), err}
+		}
+		if schema == nil {
+			continue
+		}
+		value, err := parseParam(doc, schema, raw)
+		if err != nil {
+			errs = append(errs, FieldError{field, err.Error()})
+			continue
+		}
+		validateValue(doc, schema, value, field, &errs)
+	}
+
+	if op.RequestBody != nil {
+		err := validateBody(r, doc, op.RequestBody, &errs)
+		if err != nil {
+			return err
+		}
+	}
+	if len(errs) != 0 {
+		return ValidationError{"invalid request", errs}
+	}
+	return nil
+}
+
+// validateBody validates the body of the request, which is read and replaced by its (decompressed) content
+func validateBody(r *http.Request, doc *OpenAPI, body *RequestBody, errs *[]FieldError) error {
+	err := decompressBody(r)
+	if err != nil {
+		return err
+	}
+	chunk, err := readBody(r)
+	if err != nil {
+		return err
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(chunk))
+
+	if len(chunk) == 0 {
+		if body.Required == true {
+			*errs = append(*errs, FieldError{"body", "is required"})
+		}
+		return nil
+	}
+	ctype, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	media, ok := body.Content[ctype]
+	if ok == false && strings.Contains(ctype, "/") {
+		media, ok = body.Content[strings.Split(ctype, "/")[0]+"/*"]
+	}
+	if ok == false {
+		media, ok = body.Content["*/*"]
+	}
+	if ok == false {
+		return NewAPIError(415, "unsupported Content-Type: "+ctype)
+	}
+	schema, err := doc.resolve(media.Schema)
+	if err != nil {
+		return errorTransparent{NewError500(), err}
+	}
+	if schema == nil {
+		return nil
+	}
+
+	var value interface{}
+	if ctype == "application/json" || strings.HasSuffix(ctype, "+json") {
+		err = json.Unmarshal(chunk, &value)
+		if err != nil {
+			*errs = append(*errs, FieldError{"body", "invalid JSON: " + err.Error()})
+			return nil
+		}
+	} else if ctype == "application/x-www-form-urlencoded" {
+		form, err := url.ParseQuery(string(chunk))
+		if err != nil {
+			*errs = append(*errs, FieldError{"body", "invalid form: " + err.Error()})
+			return nil
+		}
+		object := make(map[string]interface{})
+		for key := range form {
+			property, err := doc.resolve(schema.Properties[key])
+			if err != nil {
+				return errorTransparent{NewError500(), err}
+			}
+			if property == nil {
+				object[key] = form.Get(key)
+				continue
+			}
+			object[key], err = parseParam(doc, property, form.Get(key))
+			if err != nil {
+				*errs = append(*errs, FieldError{"body." + key, err.Error()})
+				return nil
+			}
+		}
+		value = object
+	} else {
+		return nil
+	}
+	validateValue(doc, schema, value, "body", errs)
+	return nil
+}
+
+var typeNames = map[string]string{
+	"integer": "an integer",
+	"number":  "a number",
+}
+
+// parseParam converts a raw parameter to the JSON value described by its schema, arrays being comma separated
+func parseParam(doc *OpenAPI, schema *Schema, raw string) (interface{}, error) {
+	switch schema.Type {
+	case "integer", "number":
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("must be %s", typeNames[schema.Type])
+		}
+		return f, nil
+	case "boolean":
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("must be a boolean")
+		}
+		return b, nil
+	case "array":
+		items, err := doc.resolve(schema.Items)
+		if err != nil {
+			return nil, err
+		}
+		values := []interface{}{}
+		for _, part := range strings.Split(raw, ",") {
+			if items == nil {
+				values = append(values, part)
+				continue
+			}
+			value, err := parseParam(doc, items, part)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	}
+	return raw, nil
+}
+
+// validateValue validates a decoded JSON value against its schema, the errors are appended to errs
+func validateValue(doc *OpenAPI, schema *Schema, value interface{}, field string, errs *[]FieldError) {
+	schema, err := doc.resolve(schema)
+	if err != nil {
+		*errs = append(*errs, FieldError{field, "invalid schema: " + err.Error()})
+		return
+	}
+	if schema == nil {
+		return
+	}
+	if value == nil {
+		if schema.Nullable == false && schema.Type != "" {
+			*errs = append(*errs, FieldError{field, "must not be null"})
+		}
+		return
+	}
+
+	switch schema.Type {
+	case "integer", "number":
+		f, ok := value.(float64)
+		if ok == false || (schema.Type == "integer" && math.Trunc(f) != f) {
+			*errs = append(*errs, FieldError{field, "must be " + typeNames[schema.Type]})
+			return
+		}
+		if schema.Minimum != nil && f < *schema.Minimum {
+			*errs = append(*errs, FieldError{field, fmt.Sprintf("must be greater than or equal to %v", *schema.Minimum)})
+		}
+		if schema.Maximum != nil && f > *schema.Maximum {
+			*errs = append(*errs, FieldError{field, fmt.Sprintf("must be lower than or equal to %v", *schema.Maximum)})
+		}
+	case "boolean":
+		if _, ok := value.(bool); ok == false {
+			*errs = append(*errs, FieldError{field, "must be a boolean"})
+			return
+		}
+	case "string":
+		s, ok := value.(string)
+		if ok == false {
+			*errs = append(*errs, FieldError{field, "must be a string"})
+			return
+		}
+		length := len([]rune(s))
+		if schema.MinLength != nil && length < *schema.MinLength {
+			*errs = append(*errs, FieldError{field, fmt.Sprintf("must be at least %d characters long", *schema.MinLength)})
+		}
+		if schema.MaxLength != nil && length > *schema.MaxLength {
+			*errs = append(*errs, FieldError{field, fmt.Sprintf("must be at most %d characters long", *schema.MaxLength)})
+		}
+		if schema.Pattern != "" {
+			if matched, err := regexp.MatchString(schema.Pattern, s); err == nil && matched == false {
+				*errs = append(*errs, FieldError{field, "must match " + schema.Pattern})
+			}
+		}
+		if schema.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, s); err != nil {
+				*errs = append(*errs, FieldError{field, "must be a RFC 3339 date-time"})
+			}
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if ok == false {
+			*errs = append(*errs, FieldError{field, "must be an array"})
+			return
+		}
+		for i, item := range items {
+			validateValue(doc, schema.Items, item, field+"["+strconv.Itoa(i)+"]", errs)
+		}
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if ok == false {
+			*errs = append(*errs, FieldError{field, "must be an object"})
+			return
+		}
+		for _, name := range schema.Required {
+			if _, ok := object[name]; ok == false {
+				*errs = append(*errs, FieldError{field + "." + name, "is required"})
+			}
+		}
+		names := make([]string, 0, len(object))
+		for name := range object {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if propertySchema, ok := schema.Properties[name]; ok == true {
+				validateValue(doc, propertySchema, object[name], field+"."+name, errs)
+			} else if schema.AdditionalProperties != nil {
+				validateValue(doc, schema.AdditionalProperties, object[name], field+"."+name, errs)
+			}
+		}
+	}
+
+	if len(schema.Enum) != 0 {
+		for _, allowed := range schema.Enum {
+			if fmt.Sprint(allowed) == fmt.Sprint(value) {
+				return
+			}
+		}
+		*errs = append(*errs, FieldError{field, fmt.Sprintf("must be one of %v", schema.Enum)})
+	}
+}
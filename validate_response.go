@@ -0,0 +1,101 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ResponseValidation is the policy applied to the responses not matching their OpenAPI description
+type ResponseValidation int
+
+const (
+	// ResponseValidationOff disables the validation of the responses
+	ResponseValidationOff ResponseValidation = iota
+	// ResponseValidationLog logs the mismatches, the responses are sent anyway
+	ResponseValidationLog
+	// ResponseValidationFail logs the mismatches and replaces the responses by a 500
+	ResponseValidationFail
+)
+
+type responseValidation struct {
+	doc    *OpenAPI
+	policy ResponseValidation
+}
+
+// ValidateResponses makes the router validate the responses of the controllers against their description in doc:
+// status code and JSON body. It is meant for development, in order to catch contract drifts before the clients do.
+// Streamed and raw responses are not validated.
+func (r *Router) ValidateResponses(doc *OpenAPI, policy ResponseValidation) {
+	r.mu.Lock()
+	r.responseValidation = responseValidation{doc, policy}
+	r.mu.Unlock()
+}
+
+// validateResponse validates the response of a controller, it returns an error if the response must not be sent
+func validateResponse(r *http.Request, code int, resp interface{}) error {
+	router := routerFromRequest(r)
+	if router == nil {
+		return nil
+	}
+	router.mu.RLock()
+	validation := router.responseValidation
+	router.mu.RUnlock()
+	if validation.policy == ResponseValidationOff || validation.doc == nil {
+		return nil
+	}
+	_, op, _ := validation.doc.find(r.Method, r.URL.Path)
+	if op == nil {
+		return nil
+	}
+
+	errs := checkResponse(validation.doc, op, code, resp)
+	if len(errs) == 0 {
+		return nil
+	}
+	log.Printf("response of %s %s does not match its description: %+v\n", r.Method, r.URL.Path, errs)
+	if validation.policy == ResponseValidationFail {
+		return ValidationError{"invalid response", errs}
+	}
+	return nil
+}
+
+func checkResponse(doc *OpenAPI, op *Operation, code int, resp interface{}) []FieldError {
+	var errs []FieldError
+
+	status := strconv.Itoa(code)
+	response, ok := op.Responses[status]
+	if ok == false {
+		response, ok = op.Responses[status[:1]+"XX"]
+	}
+	if ok == false {
+		response, ok = op.Responses["default"]
+	}
+	if ok == false {
+		return []FieldError{{"status", fmt.Sprintf("status %d is not declared", code)}}
+	}
+
+	var schema *Schema
+	for ctype, media := range response.Content {
+		if ctype == "application/json" || strings.HasSuffix(ctype, "+json") {
+			schema = media.Schema
+		}
+	}
+	if schema == nil {
+		return nil
+	}
+	chunk, err := json.Marshal(resp)
+	if err != nil {
+		return []FieldError{{"body", err.Error()}}
+	}
+	var value interface{}
+	err = json.Unmarshal(chunk, &value)
+	if err != nil {
+		return []FieldError{{"body", err.Error()}}
+	}
+	validateValue(doc, schema, value, "body", &errs)
+	return errs
+}
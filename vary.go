@@ -0,0 +1,94 @@
+package rest
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Vary declares that the response depends on the given request headers, they are added to the Vary header of the
+// response so that the caches (CDN, browsers) keep a version per value. The headers read by the router (Accept for
+// the output format, Accept-Language with Language, Origin with CORS) are declared automatically.
+func Vary(r *http.Request, headers ...string) {
+	state := stateFromRequest(r)
+	if state.w != nil {
+		addVary(state.w.Header(), headers...)
+	}
+}
+
+// addVary adds the names to the Vary header, unless they are already listed
+func addVary(h http.Header, names ...string) {
+	for _, name := range names {
+		name = http.CanonicalHeaderKey(name)
+		found := false
+		for _, value := range h.Values("Vary") {
+			for _, listed := range strings.Split(value, ",") {
+				listed = strings.TrimSpace(listed)
+				if listed == "*" || http.CanonicalHeaderKey(listed) == name {
+					found = true
+				}
+			}
+		}
+		if found == false {
+			h.Add("Vary", name)
+		}
+	}
+}
+
+// Language returns the language of supported preferred by the client (Accept-Language header), the first one if
+// the client has no preference among them. A language matches the more specific ones: "en" matches "en-US".
+// The Content-Language of the response is set, and Accept-Language is added to its Vary header.
+func Language(r *http.Request, supported ...string) string {
+	if len(supported) == 0 {
+		return ""
+	}
+	Vary(r, "Accept-Language")
+	lang := negotiateLanguage(r.Header.Get("Accept-Language"), supported)
+	if w := stateFromRequest(r).w; w != nil {
+		w.Header().Set("Content-Language", lang)
+	}
+	return lang
+}
+
+// negotiateLanguage returns the supported language with the highest quality in header
+func negotiateLanguage(header string, supported []string) string {
+	type pref struct {
+		tag string
+		q   float64
+	}
+	var prefs []pref
+
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") == true {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q > 0 {
+			prefs = append(prefs, pref{tag, q})
+		}
+	}
+	sort.SliceStable(prefs, func(i, j int) bool { return prefs[i].q > prefs[j].q })
+	for _, p := range prefs {
+		if p.tag == "*" {
+			return supported[0]
+		}
+		for _, lang := range supported {
+			l := strings.ToLower(lang)
+			if l == p.tag || strings.HasPrefix(p.tag, l+"-") == true {
+				return lang
+			}
+		}
+	}
+	return supported[0]
+}
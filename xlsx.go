@@ -0,0 +1,243 @@
+package rest
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Sheet is a worksheet of an XLSX workbook. The cells can be numbers, booleans, strings or any value formatted with
+// fmt (encoding.TextMarshaler is honored), NaN and the infinities are written as strings. The name is made valid for
+// Excel: the characters []:*?/\ are replaced by _ and it is cut to 31 characters, the empty or duplicated names
+// are replaced by SheetN.
+type Sheet struct {
+	Name string
+	Rows [][]interface{}
+}
+
+// NewSheet creates a Sheet from a slice of structs, with a header row. The columns are selected like for CSV
+// responses, with the csv tag.
+func NewSheet(name string, data interface{}) Sheet {
+	sheet := Sheet{Name: name}
+	val := reflect.ValueOf(data)
+	for val.Kind() == reflect.Ptr {
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Slice && val.Kind() != reflect.Array {
+		return sheet
+	}
+	t := val.Type().Elem()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return sheet
+	}
+	indexes, headers := csvColumns(t)
+	header := make([]interface{}, len(headers))
+	for i := range headers {
+		header[i] = headers[i]
+	}
+	sheet.Rows = append(sheet.Rows, header)
+	for i := 0; i < val.Len(); i++ {
+		item := val.Index(i)
+		for item.Kind() == reflect.Ptr {
+			item = item.Elem()
+		}
+		if item.IsValid() == false {
+			continue
+		}
+		row := make([]interface{}, len(indexes))
+		for j, index := range indexes {
+			row[j] = item.Field(index).Interface()
+		}
+		sheet.Rows = append(sheet.Rows, row)
+	}
+	return sheet
+}
+
+type xlsxResp struct {
+	sheets []Sheet
+}
+
+// XLSX creates a response streaming an Excel workbook, downloaded as filename
+func XLSX(filename string, sheets ...Sheet) interface{} {
+	h := make(http.Header)
+	h.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	return headerResp{
+		value: xlsxResp{sheets},
+		h:     h,
+	}
+}
+
+func (x xlsxResp) ContentType() string {
+	return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+}
+
+func (x xlsxResp) Stream(w io.Writer) error {
+	archive := zip.NewWriter(w)
+	err := x.writeWorkbook(archive)
+	if err != nil {
+		return err
+	}
+	for i := range x.sheets {
+		part, err := archive.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1))
+		if err != nil {
+			return err
+		}
+		err = writeSheet(part, x.sheets[i])
+		if err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+func (x xlsxResp) writeWorkbook(archive *zip.Writer) error {
+	contentTypes := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`
+	workbook := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`
+	workbookRels := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId0" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`
+	names := xlsxSheetNames(x.sheets)
+	for i, name := range names {
+		contentTypes += fmt.Sprintf(`<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+		workbook += fmt.Sprintf(`<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escapeXML(name), i+1, i+1)
+		workbookRels += fmt.Sprintf(`<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+	}
+	contentTypes += `</Types>`
+	workbook += `</sheets></workbook>`
+	workbookRels += `</Relationships>`
+
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", contentTypes},
+		{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", workbook},
+		{"xl/_rels/workbook.xml.rels", workbookRels},
+		{"xl/styles.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+			`<fonts count="1"><font/></fonts><fills count="1"><fill/></fills><borders count="1"><border/></borders>` +
+			`<cellStyleXfs count="1"><xf/></cellStyleXfs><cellXfs count="1"><xf/></cellXfs></styleSheet>`},
+	}
+	for _, part := range parts {
+		writer, err := archive.Create(part.name)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(writer, part.content)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeSheet writes the rows of the sheet one by one
+func writeSheet(w io.Writer, sheet Sheet) error {
+	buf := bufio.NewWriter(w)
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for i, row := range sheet.Rows {
+		fmt.Fprintf(buf, `<row r="%d">`, i+1)
+		for j, cell := range row {
+			ref := xlsxColumn(j) + strconv.Itoa(i+1)
+			val := reflect.ValueOf(cell)
+			for val.Kind() == reflect.Ptr && val.IsNil() == false {
+				val = val.Elem()
+			}
+			switch val.Kind() {
+			case reflect.Invalid, reflect.Ptr:
+				continue
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+				reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+				fmt.Fprintf(buf, `<c r="%s"><v>%v</v></c>`, ref, val.Interface())
+			case reflect.Float32, reflect.Float64:
+				if f := val.Float(); math.IsNaN(f) == true || math.IsInf(f, 0) == true {
+					// Excel has no number for them
+					fmt.Fprintf(buf, `<c r="%s" t="inlineStr"><is><t>%s</t></is></c>`, ref, strconv.FormatFloat(f, 'g', -1, 64))
+				} else {
+					fmt.Fprintf(buf, `<c r="%s"><v>%v</v></c>`, ref, val.Interface())
+				}
+			case reflect.Bool:
+				b := 0
+				if val.Bool() == true {
+					b = 1
+				}
+				fmt.Fprintf(buf, `<c r="%s" t="b"><v>%d</v></c>`, ref, b)
+			default:
+				fmt.Fprintf(buf, `<c r="%s" t="inlineStr"><is><t>%s</t></is></c>`, ref, escapeXML(csvValue(val)))
+			}
+		}
+		buf.WriteString(`</row>`)
+	}
+	buf.WriteString(`</sheetData></worksheet>`)
+	return buf.Flush()
+}
+
+// xlsxSheetNames returns the valid names of the sheets (see xlsxSheetName). The names must be unique regardless of
+// the case, the empty or duplicated names are replaced by the first free SheetN, from the position of the sheet.
+func xlsxSheetNames(sheets []Sheet) []string {
+	names := make([]string, len(sheets))
+	used := make(map[string]bool)
+	for i := range sheets {
+		name := xlsxSheetName(sheets[i].Name)
+		for n := i + 1; name == "" || used[strings.ToLower(name)] == true; n++ {
+			name = fmt.Sprintf("Sheet%d", n)
+		}
+		used[strings.ToLower(name)] = true
+		names[i] = name
+	}
+	return names
+}
+
+// xlsxSheetName returns name without the characters forbidden by Excel, cut to its maximum of 31 characters
+func xlsxSheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) == true {
+			return '_'
+		}
+		return r
+	}, name)
+	// the names can't start or end with an apostrophe either
+	name = strings.Trim(name, "'")
+	if runes := []rune(name); len(runes) > 31 {
+		name = strings.Trim(string(runes[:31]), "'")
+	}
+	return name
+}
+
+// xlsxColumn returns the name of the column i (0 is A, 26 is AA)
+func xlsxColumn(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+func escapeXML(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,70 @@
+package rest
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestXLSXSheetName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"Users", "Users"},
+		{"2024/01: [draft]?", "2024_01_ _draft__"},
+		{`a*b\c`, "a_b_c"},
+		{"'quoted'", "quoted"},
+		{strings.Repeat("é", 40), strings.Repeat("é", 31)},
+		{"", ""},
+	}
+	for _, test := range tests {
+		if name := xlsxSheetName(test.name); name != test.expected {
+			t.Errorf("%q: got %q, expected %q", test.name, name, test.expected)
+		}
+	}
+}
+
+func TestXLSXSheetNames(t *testing.T) {
+	tests := []struct {
+		names    []string
+		expected []string
+	}{
+		{[]string{"Users", "Orders"}, []string{"Users", "Orders"}},
+		{[]string{"Sheet2", ""}, []string{"Sheet2", "Sheet3"}},
+		{[]string{"", "Sheet1"}, []string{"Sheet1", "Sheet2"}},
+		{[]string{"users", "USERS", "Sheet2"}, []string{"users", "Sheet2", "Sheet3"}},
+		{[]string{"a/b", "a:b", "a_b"}, []string{"a_b", "Sheet2", "Sheet3"}},
+		{[]string{strings.Repeat("x", 31) + "1", strings.Repeat("x", 31) + "2"}, []string{strings.Repeat("x", 31), "Sheet2"}},
+	}
+	for _, test := range tests {
+		sheets := make([]Sheet, len(test.names))
+		for i, name := range test.names {
+			sheets[i].Name = name
+		}
+		names := xlsxSheetNames(sheets)
+		if strings.Join(names, ",") != strings.Join(test.expected, ",") {
+			t.Errorf("%q: got %q, expected %q", test.names, names, test.expected)
+		}
+	}
+}
+
+func TestXLSXNonFinite(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		expected string
+	}{
+		{1.5, `<c r="A1"><v>1.5</v></c>`},
+		{math.NaN(), `<c r="A1" t="inlineStr"><is><t>NaN</t></is></c>`},
+		{math.Inf(1), `<c r="A1" t="inlineStr"><is><t>+Inf</t></is></c>`},
+		{float32(math.Inf(-1)), `<c r="A1" t="inlineStr"><is><t>-Inf</t></is></c>`},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		err := writeSheet(&buf, Sheet{Rows: [][]interface{}{{test.value}}})
+		if err != nil || strings.Contains(buf.String(), test.expected) == false {
+			t.Errorf("%v: got %s (%v)", test.value, buf.String(), err)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"reflect"
+	"regexp"
+	"strconv"
+)
+
+// XMLOptions sets how the XML bodies are decoded and the XML responses encoded, see SetXMLOptions
+type XMLOptions struct {
+	// AllowDTD accepts the documents with a document type declaration, they are rejected otherwise. The internal
+	// entities it declares are expanded, the external and parameter entities are never loaded.
+	AllowDTD bool
+	// MaxEntityExpansion is the maximum number of bytes the entities declared by the DTD expand to, in total
+	MaxEntityExpansion int
+	// MaxDepth and MaxSize bound the documents, the limits of Parse and DecodeBody are used if 0 (see ParseLimits).
+	// Parse limits the size of the bodies by the MaxBodySize of the route only.
+	MaxDepth int
+	MaxSize  int64
+	// RootName is the name of the root element of the responses, replacing the name of their type or XMLName.
+	// The slices are encoded as a RootName element holding an ItemName element ("item" if empty) per value.
+	RootName string
+	ItemName string
+}
+
+// DefaultXMLOptions are the XML options of the routers, and of DecodeBody
+var DefaultXMLOptions = XMLOptions{
+	MaxEntityExpansion: 64 << 10,
+}
+
+// SetXMLOptions sets how the router decodes the XML bodies and encodes the XML responses
+func (r *Router) SetXMLOptions(opts XMLOptions) {
+	r.mu.Lock()
+	r.xmlOptions = &opts
+	r.mu.Unlock()
+}
+
+// getXMLOptions returns the XML options of the router, the default ones if it has none
+func (r *Router) getXMLOptions() XMLOptions {
+	if r == nil {
+		return DefaultXMLOptions
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.xmlOptions == nil {
+		return DefaultXMLOptions
+	}
+	return *r.xmlOptions
+}
+
+// decodeXML decodes the XML document chunk into v, enforcing opts and limits
+func decodeXML(chunk []byte, v interface{}, opts XMLOptions, limits ParseLimits) error {
+	if opts.MaxSize == 0 {
+		opts.MaxSize = limits.MaxSize
+	}
+	if opts.MaxDepth == 0 {
+		opts.MaxDepth = limits.MaxDepth
+	}
+	if opts.MaxSize > 0 && int64(len(chunk)) > opts.MaxSize {
+		return NewAPIError(413, "request body too large")
+	}
+	d := xml.NewDecoder(bytes.NewReader(chunk))
+	d.Entity = make(map[string]string)
+	return xml.NewTokenDecoder(&xmlTokenReader{d: d, chunk: chunk, opts: opts}).Decode(v)
+}
+
+// xmlTokenReader reads the raw tokens of an XML document, failing on the DTDs if they are not allowed and when the
+// limits are exceeded
+type xmlTokenReader struct {
+	d     *xml.Decoder
+	chunk []byte
+	opts  XMLOptions
+	depth int
+}
+
+func (t *xmlTokenReader) Token() (xml.Token, error) {
+	tok, err := t.d.RawToken()
+	switch tok := tok.(type) {
+	case xml.StartElement:
+		t.depth++
+		if t.opts.MaxDepth > 0 && t.depth > t.opts.MaxDepth {
+			return nil, errors.New("the document is nested deeper than " + strconv.Itoa(t.opts.MaxDepth) + " levels")
+		}
+	case xml.EndElement:
+		t.depth--
+	case xml.Directive:
+		if t.opts.AllowDTD == false {
+			return nil, errors.New("document type declarations are not allowed")
+		}
+		if err := t.declare(tok); err != nil {
+			return nil, err
+		}
+	}
+	return tok, err
+}
+
+// entityDecl matches the declarations of the internal general entities
+var entityDecl = regexp.MustCompile(`<!ENTITY\s+([^\s%"'<>]+)\s+(?:"([^"]*)"|'([^']*)')\s*>`)
+
+// declare adds the internal entities of the document type declaration dtd to the decoder, once checked that the
+// rest of the document does not expand them beyond the limit
+func (t *xmlTokenReader) declare(dtd xml.Directive) error {
+	entities := make(map[string]string)
+	for _, m := range entityDecl.FindAllSubmatch(dtd, -1) {
+		entities[string(m[1])] = string(m[2]) + string(m[3])
+	}
+	if len(entities) == 0 {
+		return nil
+	}
+
+	// the references in the comments and the CDATA sections are counted too, they can only overestimate
+	expansion := 0
+	rest := t.chunk[t.d.InputOffset():]
+	for {
+		i := bytes.IndexByte(rest, '&')
+		if i < 0 {
+			break
+		}
+		rest = rest[i+1:]
+		end := bytes.IndexByte(rest, ';')
+		if end < 0 {
+			break
+		}
+		if value, ok := entities[string(rest[:end])]; ok == true {
+			expansion += len(value)
+			if expansion > t.opts.MaxEntityExpansion {
+				return errors.New("the entities expand beyond " + strconv.Itoa(t.opts.MaxEntityExpansion) + " bytes")
+			}
+		}
+	}
+	for name, value := range entities {
+		t.d.Entity[name] = value
+	}
+	return nil
+}
+
+// marshalXML encodes v as the root element of an XML response, named as set by opts
+func marshalXML(v interface{}, opts XMLOptions) ([]byte, error) {
+	if opts.RootName == "" {
+		return xml.Marshal(v)
+	}
+
+	var buf bytes.Buffer
+	e := xml.NewEncoder(&buf)
+	root := xml.StartElement{Name: xml.Name{Local: opts.RootName}}
+	val := reflect.ValueOf(v)
+	if val.IsValid() == true && (val.Kind() == reflect.Slice || val.Kind() == reflect.Array) &&
+		val.Type().Elem().Kind() != reflect.Uint8 {
+		item := xml.StartElement{Name: xml.Name{Local: opts.ItemName}}
+		if item.Name.Local == "" {
+			item.Name.Local = "item"
+		}
+		if err := e.EncodeToken(root); err != nil {
+			return nil, err
+		}
+		for i := 0; i < val.Len(); i++ {
+			if err := e.EncodeElement(val.Index(i).Interface(), item); err != nil {
+				return nil, err
+			}
+		}
+		if err := e.EncodeToken(root.End()); err != nil {
+			return nil, err
+		}
+	} else if err := e.EncodeElement(v, root); err != nil {
+		return nil, err
+	}
+	if err := e.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,84 @@
+package rest
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type xmlThing struct {
+	XMLName xml.Name `xml:"thing"`
+	Name    string   `xml:"name"`
+}
+
+func TestXMLOptions(t *testing.T) {
+	billion := `<!DOCTYPE a [<!ENTITY a "aaaaaaaaaa">]><thing><name>` + strings.Repeat("&a;", 20) + `</name></thing>`
+	tests := []struct {
+		name  string
+		opts  XMLOptions
+		body  string
+		valid bool
+		value string
+	}{
+		{"plain", DefaultXMLOptions, `<thing><name>x</name></thing>`, true, "x"},
+		{"predefined entity", DefaultXMLOptions, `<thing><name>&amp;</name></thing>`, true, "&"},
+		{"DTD forbidden", DefaultXMLOptions, `<!DOCTYPE a []><thing><name>x</name></thing>`, false, ""},
+		{"entity", XMLOptions{AllowDTD: true, MaxEntityExpansion: 100},
+			`<!DOCTYPE a [<!ENTITY e "expanded">]><thing><name>&e;</name></thing>`, true, "expanded"},
+		{"entity expansion", XMLOptions{AllowDTD: true, MaxEntityExpansion: 100}, billion, false, ""},
+		{"nested entity not expanded", XMLOptions{AllowDTD: true, MaxEntityExpansion: 100},
+			`<!DOCTYPE a [<!ENTITY a "x"><!ENTITY b "&a;&a;">]><thing><name>&b;</name></thing>`, true, "&a;&a;"},
+		{"external entity", XMLOptions{AllowDTD: true, MaxEntityExpansion: 100},
+			`<!DOCTYPE a [<!ENTITY e SYSTEM "file:///etc/passwd">]><thing><name>&e;</name></thing>`, false, ""},
+		{"depth", XMLOptions{MaxDepth: 3}, `<thing><name><a><b/></a></name></thing>`, false, ""},
+		{"size", XMLOptions{MaxSize: 10}, `<thing><name>x</name></thing>`, false, ""},
+	}
+	for _, test := range tests {
+		var v xmlThing
+		err := decodeXML([]byte(test.body), &v, test.opts, ParseLimits{})
+		if (err == nil) != test.valid {
+			t.Errorf("%s: got error %v", test.name, err)
+		}
+		if err == nil && v.Name != test.value {
+			t.Errorf("%s: got %q, expected %q", test.name, v.Name, test.value)
+		}
+	}
+}
+
+func TestXMLParseSize(t *testing.T) {
+	router := New()
+	router.POST("/things", func(r *http.Request, p Params) (interface{}, error) {
+		var v xmlThing
+		if err := Parse(r, &v); err != nil {
+			return nil, err
+		}
+		return "ok", nil
+	}).WithMaxBodySize(50 << 20)
+
+	body := `<thing><name>` + strings.Repeat("a", 11<<20) + `</name></thing>`
+	req := httptest.NewRequest("POST", "/things", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/xml")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != 200 {
+		t.Errorf("got %d, expected 200", w.Code)
+	}
+}
+
+func TestXMLRootName(t *testing.T) {
+	router := New()
+	router.SetXMLOptions(XMLOptions{RootName: "response", ItemName: "thing"})
+	router.GET("/things", func(r *http.Request, p Params) (interface{}, error) {
+		return []xmlThing{{Name: "a"}, {Name: "b"}}, nil
+	})
+	req := httptest.NewRequest("GET", "/things", nil)
+	req.Header.Set("Accept", "application/xml")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	expected := `<response><thing><name>a</name></thing><thing><name>b</name></thing></response>`
+	if w.Body.String() != expected {
+		t.Errorf("got %s, expected %s", w.Body.String(), expected)
+	}
+}